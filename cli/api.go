@@ -0,0 +1,467 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+// The computed metrics are exposed as JSON by `report --output json` and the `serve` command. The field names
+// below are part of that API and should only ever be added to, never renamed or removed:
+//
+//	GET /api/v1/report       Report, everything below in one document
+//	GET /api/v1/daily        []DailyStatusCounts
+//	GET /api/v1/throughput   []Throughput
+//	GET /api/v1/cycle-times  []CycleTime
+//	GET /api/v1/open         []OpenIssue
+//	GET /api/v1/missing      []MissingIssue
+//	GET /api/v1/versions     []VersionProgress
+//	GET /api/v1/epics        []EpicProgress
+//	GET /api/v1/blocked      []BlockedIssue
+//	GET /api/v1/sprints      []SprintVelocity
+//
+// every endpoint accepts optional from=YYYY-MM and to=YYYY-MM query parameters, defaulting to the last year, and
+// dataset=name or instance=name to limit it to one configured dataset or jira instance. label=name, component=name
+// and fix_version=name, each repeatable, limit it to issues with any of them. weight=story_points weights the daily,
+// throughput and sprint numbers by a number field rather than counting issues, see Weight.
+
+const APIDateFormat = "2006-01-02"
+
+// Report is the full set of metrics for a date range
+type Report struct {
+	From       string              `json:"from"`
+	To         string              `json:"to"`
+	Generated  time.Time           `json:"generated"`
+	Summary    ReportSummary       `json:"summary"`
+	Daily      []DailyStatusCounts `json:"daily"`
+	Throughput []Throughput        `json:"throughput"`
+	CycleTimes []CycleTime         `json:"cycle_times"`
+	Open       []OpenIssue         `json:"open"`
+	Missing    []MissingIssue      `json:"missing"`
+	Versions   []VersionProgress   `json:"versions"`
+	Epics      []EpicProgress      `json:"epics"`
+	Blocked    []BlockedIssue      `json:"blocked"`
+	Sprints    []SprintVelocity    `json:"sprints"`
+
+	// Weight is what the daily, throughput and sprint numbers and the created and closed totals are, count of issues
+	// or the sum of a number field such as story_points
+	Weight string `json:"weight"`
+}
+
+// ReportSummary is the headline numbers for the range
+type ReportSummary struct {
+	Created         float64        `json:"created"`
+	Closed          float64        `json:"closed"`
+	Open            int            `json:"open"`
+	OpenByStatus    map[string]int `json:"open_by_status"`
+	OpenByAssignee  map[string]int `json:"open_by_assignee"`
+	OpenByPriority  map[string]int `json:"open_by_priority"`
+	OpenByLabel     map[string]int `json:"open_by_label"`
+	OpenByComponent map[string]int `json:"open_by_component"`
+	OpenByVersion   map[string]int `json:"open_by_fix_version"`
+	MedianLeadDays  float64        `json:"median_lead_days"`
+	MedianCycleDays float64        `json:"median_cycle_days"`
+}
+
+// DailyStatusCounts is the number of issues open on a day, total and by normalised status
+type DailyStatusCounts struct {
+	Date     string             `json:"date"`
+	Total    float64            `json:"total"`
+	Statuses map[string]float64 `json:"statuses"`
+}
+
+// Throughput is the number of issues created and closed in the week starting on the monday Week
+type Throughput struct {
+	Week    string  `json:"week"`
+	Created float64 `json:"created"`
+	Closed  float64 `json:"closed"`
+}
+
+// CycleTime is the lead time (created to closed) and cycle time (first in progress to closed) of a closed issue
+type CycleTime struct {
+	Instance  string  `json:"instance"`
+	Key       string  `json:"key"`
+	Type      string  `json:"type"`
+	Created   string  `json:"created"`
+	Started   string  `json:"started,omitempty"`
+	Closed    string  `json:"closed"`
+	LeadDays  float64 `json:"lead_days"`
+	CycleDays float64 `json:"cycle_days,omitempty"`
+}
+
+// OpenIssue is an issue that is currently open
+type OpenIssue struct {
+	Instance  string   `json:"instance"`
+	Key       string   `json:"key"`
+	URL       string   `json:"url"`
+	Type      string   `json:"type"`
+	Status    string   `json:"status"`
+	Group     string   `json:"status_group"` // the normalised status
+	Summary   string   `json:"summary"`
+	Labels    []string `json:"labels"`
+	Creator   string   `json:"creator"`
+	Assignee  string   `json:"assignee"`
+	Reporter  string   `json:"reporter"`
+	Priority  string   `json:"priority"`
+	Created   string   `json:"created"`
+	DaysOpen  float64  `json:"days_open"`
+	StartedOn string   `json:"started,omitempty"`
+
+	Components  []string `json:"components"`
+	FixVersions []string `json:"fix_versions"`
+}
+
+// VersionProgress is how many of a fix version's issues are open and closed, with its release date from jira
+type VersionProgress struct {
+	Instance    string `json:"instance"`
+	Project     string `json:"project,omitempty"` // empty when the version isn't in the project's versions
+	Name        string `json:"name"`
+	Released    bool   `json:"released"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Open        int    `json:"open"`
+	Closed      int    `json:"closed"`
+}
+
+// MissingIssue is an issue that stopped being returned by the jql within the range, and so is excluded from metrics
+type MissingIssue struct {
+	Instance string `json:"instance"`
+	Key      string `json:"key"`
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Summary  string `json:"summary"`
+	Missing  string `json:"missing"`
+}
+
+// reportPart is a section of a Report, so an endpoint serving only one of them doesn't compute the others
+type reportPart int
+
+const (
+	reportDaily reportPart = 1 << iota
+	reportThroughput
+	reportCycleTimes
+	reportOpen
+	reportMissing
+	reportVersions
+	reportEpics
+	reportBlocked
+	reportSprints
+
+	// every part, the summary comes with the throughput, cycle times and open issues
+	reportAll = 1<<iota - 1
+)
+
+// BuildReport computes every metric for issues in the cache over the date range
+func BuildReport(theCache *cache.Cache, model StatusModel, w *Weight, from, to time.Time) (*Report, error) {
+	return buildReport(theCache, model, w, from, to, reportAll)
+}
+
+// buildReport computes only the parts of a report, leaving the rest empty
+func buildReport(theCache *cache.Cache, model StatusModel, w *Weight, from, to time.Time, parts reportPart) (*Report, error) {
+	issues := &[]cache.Issue{}
+	var err error
+	if parts != reportMissing {
+		if issues, err = theCache.GetAllIssues(); err != nil {
+			return nil, err
+		}
+	}
+
+	var daily *DailyOpenIssuesResult
+	if parts&reportDaily != 0 {
+		if daily, err = CalcDailyOpenIssues(theCache, model, w, *issues, from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	var flows []IssueFlow
+	if parts&(reportThroughput|reportCycleTimes|reportOpen|reportVersions) != 0 {
+		if flows, err = CalcIssueFlows(theCache, model, *issues); err != nil {
+			return nil, err
+		}
+	}
+
+	r := Report{
+		From:       from.Format(APIDateFormat),
+		To:         to.Format(APIDateFormat),
+		Generated:  time.Now().UTC(),
+		Daily:      []DailyStatusCounts{},
+		Throughput: []Throughput{},
+		CycleTimes: []CycleTime{},
+		Open:       []OpenIssue{},
+		Missing:    []MissingIssue{},
+		Weight:     w.Name,
+		Summary: ReportSummary{
+			OpenByStatus:    map[string]int{},
+			OpenByAssignee:  map[string]int{},
+			OpenByPriority:  map[string]int{},
+			OpenByLabel:     map[string]int{},
+			OpenByComponent: map[string]int{},
+			OpenByVersion:   map[string]int{},
+		},
+	}
+
+	if daily != nil {
+		for _, d := range daily.Days {
+			r.Daily = append(r.Daily, DailyStatusCounts{
+				Date:     d.Date.Format(APIDateFormat),
+				Total:    d.Total,
+				Statuses: d.Statuses,
+			})
+		}
+	}
+
+	if parts&reportThroughput != 0 {
+		for _, week := range CalcWeeklyThroughput(flows, w, from, to) {
+			r.Throughput = append(r.Throughput, Throughput{
+				Week:    week.Week.Format(APIDateFormat),
+				Created: week.Created,
+				Closed:  week.Closed,
+			})
+			r.Summary.Created += week.Created
+			r.Summary.Closed += week.Closed
+		}
+	}
+
+	var leadDays, cycleDays []float64
+	now := time.Now()
+	for _, f := range flows {
+		i := f.Issue
+
+		if f.Closed == nil {
+			if parts&reportOpen == 0 {
+				continue
+			}
+
+			o := OpenIssue{
+				Instance: i.Instance,
+				Key:      i.Key,
+				URL:      i.URL,
+				Type:     i.Type,
+				Status:   i.Status,
+				Group:    model.Normalise(i.Status),
+				Summary:  i.Summary,
+				Labels:   i.Labels,
+				Creator:  i.Creator,
+				Assignee: i.Assignee,
+				Reporter: i.Reporter,
+				Priority: i.Priority,
+				Created:  i.Created.Format(APIDateFormat),
+				DaysOpen: now.Sub(i.Created).Hours() / 24,
+
+				Components:  i.Components,
+				FixVersions: i.FixVersions,
+			}
+			if f.Started != nil {
+				o.StartedOn = f.Started.Format(APIDateFormat)
+			}
+			r.Open = append(r.Open, o)
+			r.Summary.OpenByStatus[o.Group]++
+			r.Summary.OpenByAssignee[AssigneeName(i.Assignee)]++
+			r.Summary.OpenByPriority[PriorityName(i.Priority)]++
+			for _, label := range i.Labels {
+				r.Summary.OpenByLabel[label]++
+			}
+			for _, comp := range ComponentNames(i) {
+				r.Summary.OpenByComponent[comp]++
+			}
+			for _, v := range FixVersionNames(i) {
+				r.Summary.OpenByVersion[v]++
+			}
+			continue
+		}
+
+		if parts&reportCycleTimes == 0 || f.Closed.Before(from) || f.Closed.After(to) {
+			continue
+		}
+
+		ct := CycleTime{
+			Instance: i.Instance,
+			Key:      i.Key,
+			Type:     i.Type,
+			Created:  i.Created.Format(APIDateFormat),
+			Closed:   f.Closed.Format(APIDateFormat),
+			LeadDays: f.LeadDays(),
+		}
+		leadDays = append(leadDays, ct.LeadDays)
+		if f.Started != nil {
+			ct.Started = f.Started.Format(APIDateFormat)
+			ct.CycleDays = f.CycleDays()
+			cycleDays = append(cycleDays, ct.CycleDays)
+		}
+		r.CycleTimes = append(r.CycleTimes, ct)
+	}
+
+	sort.Slice(r.Open, func(a, b int) bool {
+		return r.Open[a].Created < r.Open[b].Created
+	})
+	sort.Slice(r.CycleTimes, func(a, b int) bool {
+		return r.CycleTimes[a].Closed < r.CycleTimes[b].Closed
+	})
+
+	if parts&reportMissing != 0 {
+		missing, err := theCache.GetMissingIssues()
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range *missing {
+			if i.Missing.Time.Before(from) || i.Missing.Time.After(to) {
+				continue
+			}
+
+			r.Missing = append(r.Missing, MissingIssue{
+				Instance: i.Instance,
+				Key:      i.Key,
+				URL:      i.URL,
+				Type:     i.Type,
+				Status:   i.Status,
+				Summary:  i.Summary,
+				Missing:  i.Missing.Time.Format(APIDateFormat),
+			})
+		}
+	}
+
+	if parts&reportVersions != 0 {
+		if r.Versions, err = CalcVersionProgress(theCache, flows); err != nil {
+			return nil, err
+		}
+	}
+
+	var categories map[string]string
+	if parts&(reportEpics|reportBlocked|reportSprints) != 0 {
+		if categories, err = theCache.GetStatusCategories(); err != nil {
+			return nil, err
+		}
+	}
+
+	if parts&reportEpics != 0 {
+		r.Epics = CalcEpicProgress(categories, model, FindEpics(*issues))
+	}
+
+	if parts&reportBlocked != 0 {
+		links, err := theCache.GetLinks()
+		if err != nil {
+			return nil, err
+		}
+		if r.Blocked, err = CalcBlockedIssues(theCache, *issues, CalcBlockEdges(categories, model, *issues, links), now); err != nil {
+			return nil, err
+		}
+	}
+
+	if parts&reportSprints != 0 {
+		if r.Sprints, err = CalcSprintVelocityInRange(theCache, categories, model, w, *issues, from, to, now); err != nil {
+			return nil, err
+		}
+	}
+
+	r.Summary.Open = len(r.Open)
+	r.Summary.MedianLeadDays = median(leadDays)
+	r.Summary.MedianCycleDays = median(cycleDays)
+
+	return &r, nil
+}
+
+// AssigneeName is how an issue's assignee is shown in breakdowns, with unassigned issues grouped together
+func AssigneeName(assignee string) string {
+	if assignee == "" {
+		return "Unassigned"
+	}
+	return assignee
+}
+
+// PriorityName is how an issue's priority is shown in breakdowns, some projects don't use priorities at all
+func PriorityName(priority string) string {
+	if priority == "" {
+		return "None"
+	}
+	return priority
+}
+
+// ComponentNames are the components an issue is counted under in breakdowns, those without any together
+func ComponentNames(i cache.Issue) []string {
+	if len(i.Components) == 0 {
+		return []string{"No Component"}
+	}
+	return i.Components
+}
+
+// FixVersionNames are the fix versions an issue is counted under in breakdowns, those without any together
+func FixVersionNames(i cache.Issue) []string {
+	if len(i.FixVersions) == 0 {
+		return []string{"Unscheduled"}
+	}
+	return i.FixVersions
+}
+
+// CalcVersionProgress counts the open and closed issues of each fix version, in release date order with versions jira
+// didn't return, such as those of projects we don't fetch, last
+func CalcVersionProgress(theCache *cache.Cache, flows []IssueFlow) ([]VersionProgress, error) {
+	versions, err := theCache.GetVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := map[string]*VersionProgress{}
+	var order []string
+	for _, v := range versions {
+		k := v.Instance + "/" + v.Project + "/" + v.Name
+		p := VersionProgress{Instance: v.Instance, Project: v.Project, Name: v.Name, Released: v.Released}
+		if v.ReleaseDate.Valid {
+			p.ReleaseDate = v.ReleaseDate.Time.Format(APIDateFormat)
+		}
+		progress[k] = &p
+		order = append(order, k)
+	}
+
+	var unknown []string
+	for _, f := range flows {
+		i := f.Issue
+		project := i.Key
+		if n := strings.LastIndex(i.Key, "-"); n > 0 {
+			project = i.Key[:n]
+		}
+
+		for _, name := range i.FixVersions {
+			k := i.Instance + "/" + project + "/" + name
+			if progress[k] == nil {
+				k = i.Instance + "//" + name
+				if progress[k] == nil {
+					progress[k] = &VersionProgress{Instance: i.Instance, Name: name}
+					unknown = append(unknown, k)
+				}
+			}
+
+			if f.Closed == nil {
+				progress[k].Open++
+			} else {
+				progress[k].Closed++
+			}
+		}
+	}
+	sort.Strings(unknown)
+
+	result := []VersionProgress{}
+	for _, k := range append(order, unknown...) {
+		if p := progress[k]; p.Open+p.Closed > 0 {
+			result = append(result, *p)
+		}
+	}
+
+	return result, nil
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	m := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[m-1] + sorted[m]) / 2
+	}
+	return sorted[m]
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+// BlockedIssue is an open issue with at least one blocker that isn't done
+type BlockedIssue struct {
+	Instance    string   `json:"instance"`
+	Key         string   `json:"key"`
+	URL         string   `json:"url"`
+	Type        string   `json:"type"`
+	Status      string   `json:"status"`
+	Summary     string   `json:"summary"`
+	BlockedBy   []string `json:"blocked_by"`
+	Since       string   `json:"since"` // when the earliest current blocker was linked, or created if jira didn't record it
+	DaysBlocked float64  `json:"days_blocked"`
+	Depth       int      `json:"depth"` // the longest chain of open blockers behind it, 1 when its blockers aren't blocked
+}
+
+// BlockEdge is Blocker blocking Key, both still open
+type BlockEdge struct {
+	Instance string
+	Blocker  string
+	Key      string
+}
+
+// IsBlockedBy is true for a link that says its issue is blocked by the linked one
+func IsBlockedBy(l cache.Link) bool {
+	return (strings.EqualFold(l.Type, "Blocks") && l.Direction == cache.LinkInward) || strings.EqualFold(l.Relation, "is blocked by")
+}
+
+// IsBlocking is true for a link that says its issue blocks the linked one
+func IsBlocking(l cache.Link) bool {
+	return (strings.EqualFold(l.Type, "Blocks") && l.Direction == cache.LinkOutward) || strings.EqualFold(l.Relation, "blocks")
+}
+
+// CalcBlockEdges are the blocks links between issues that are both open, from either end as an issue may only be cached
+// at one of them. statuses come from the cache when the issue is in it, otherwise as they were when the link was fetched
+func CalcBlockEdges(categories map[string]string, model StatusModel, issues []cache.Issue, links []cache.Link) []BlockEdge {
+	statuses := map[string]string{}
+	for _, l := range links {
+		statuses[l.Instance+"/"+l.LinkedKey] = l.LinkedStatus
+	}
+	for _, i := range issues {
+		statuses[i.Instance+"/"+i.Key] = i.Status
+	}
+	open := func(instance, key string) bool {
+		return StatusCategory(categories, model, instance, statuses[instance+"/"+key]) != "Done"
+	}
+
+	seen := map[BlockEdge]bool{}
+	var edges []BlockEdge
+	for _, l := range links {
+		e := BlockEdge{Instance: l.Instance}
+		switch {
+		case IsBlockedBy(l):
+			e.Blocker, e.Key = l.LinkedKey, l.Key
+		case IsBlocking(l):
+			e.Blocker, e.Key = l.Key, l.LinkedKey
+		default:
+			continue
+		}
+
+		if seen[e] || !open(e.Instance, e.Blocker) || !open(e.Instance, e.Key) {
+			continue
+		}
+		seen[e] = true
+		edges = append(edges, e)
+	}
+
+	sort.Slice(edges, func(a, b int) bool {
+		if edges[a].Key != edges[b].Key {
+			return edges[a].Key < edges[b].Key
+		}
+		return edges[a].Blocker < edges[b].Blocker
+	})
+
+	return edges
+}
+
+// CalcBlockedIssues lists the open cached issues with open blockers, longest blocked first. how long is from the link
+// events jira records on either issue as "This issue is blocked by AB-1" and "This issue blocks AB-2"
+func CalcBlockedIssues(theCache *cache.Cache, issues []cache.Issue, edges []BlockEdge, now time.Time) ([]BlockedIssue, error) {
+	blockers := map[string][]string{}
+	for _, e := range edges {
+		k := e.Instance + "/" + e.Key
+		blockers[k] = append(blockers[k], e.Blocker)
+	}
+
+	depths := map[string]int{}
+	var depth func(instance, key string, visiting map[string]bool) int
+	depth = func(instance, key string, visiting map[string]bool) int {
+		k := instance + "/" + key
+		if d, ok := depths[k]; ok {
+			return d
+		}
+		if visiting[k] || len(blockers[k]) == 0 {
+			return 0 // a cycle, or nothing blocking it
+		}
+		visiting[k] = true
+
+		d := 0
+		for _, b := range blockers[k] {
+			d = max(d, depth(instance, b, visiting))
+		}
+		delete(visiting, k)
+
+		depths[k] = d + 1
+		return d + 1
+	}
+
+	result := []BlockedIssue{}
+	for _, i := range issues {
+		by := blockers[i.Instance+"/"+i.Key]
+		if len(by) == 0 {
+			continue
+		}
+
+		since, err := blockedSince(theCache, i, by)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, BlockedIssue{
+			Instance:    i.Instance,
+			Key:         i.Key,
+			URL:         i.URL,
+			Type:        i.Type,
+			Status:      i.Status,
+			Summary:     i.Summary,
+			BlockedBy:   by,
+			Since:       since.Format(APIDateFormat),
+			DaysBlocked: now.Sub(since).Hours() / 24,
+			Depth:       depth(i.Instance, i.Key, map[string]bool{}),
+		})
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].DaysBlocked != result[b].DaysBlocked {
+			return result[a].DaysBlocked > result[b].DaysBlocked
+		}
+		return result[a].Key < result[b].Key
+	})
+
+	return result, nil
+}
+
+// blockedSince is when the earliest of an issue's current blockers was last linked to it
+func blockedSince(theCache *cache.Cache, i cache.Issue, blockers []string) (time.Time, error) {
+	events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "Link")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting link events for %s: %w", i.Key, err)
+	}
+
+	var since time.Time
+	for _, b := range blockers {
+		linked := linkedAt(events, b)
+		if linked.IsZero() {
+			// the link may only be recorded on the blocker
+			blockerEvents, err := theCache.GetIssueEventsForField(i.Instance, b, "Link")
+			if err != nil {
+				return time.Time{}, fmt.Errorf("getting link events for %s: %w", b, err)
+			}
+			linked = linkedAt(blockerEvents, i.Key)
+		}
+		if linked.IsZero() {
+			linked = i.Created
+		}
+
+		if since.IsZero() || linked.Before(since) {
+			since = linked
+		}
+	}
+
+	return since, nil
+}
+
+// linkedAt is when a link to key was last added in the link events, zero if it never was
+func linkedAt(events []cache.Event, key string) time.Time {
+	var at time.Time
+	for _, e := range events {
+		if strings.HasSuffix(e.To, " "+key) {
+			at = e.Date
+		}
+	}
+	return at
+}
@@ -3,7 +3,9 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
 	"github.com/katbyte/gogo-jira-stats/version"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
@@ -22,6 +24,48 @@ func ValidateParams(params []string) func(cmd *cobra.Command, args []string) err
 	}
 }
 
+// ParseMonthRange parses optional [YYYY-MM] [YYYY-MM] args, defaulting to the start of the month for from till now. a
+// single arg is from then till now for every command, graphs used to ignore it
+func ParseMonthRange(args []string, defaultFrom time.Time) (time.Time, time.Time, error) {
+	var err error
+
+	from := time.Date(defaultFrom.Year(), defaultFrom.Month(), 1, 0, 0, 0, 0, defaultFrom.Location())
+	to := time.Now()
+
+	if len(args) > 0 {
+		from, err = time.Parse("2006-01", args[0])
+		if err != nil {
+			return from, to, fmt.Errorf("failed to parse time %s : %w", args[0], err)
+		}
+	}
+
+	if len(args) > 1 {
+		to, err = time.Parse("2006-01", args[1])
+		if err != nil {
+			return from, to, fmt.Errorf("failed to parse time %s : %w", args[1], err)
+		}
+	}
+
+	return from, to, nil
+}
+
+// OpenCache opens the cache with the options from flags applied
+func OpenCache(f FlagData) (*cache.Cache, error) {
+	theCache, err := cache.Open(f.CachePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening cache %s: %w", f.CachePath, err)
+	}
+
+	theCache.IncludeMissing = f.IncludeMissing
+	theCache.Dataset = f.Dataset
+	theCache.Instance = f.Instance
+	theCache.Labels = f.Labels
+	theCache.Components = f.Components
+	theCache.FixVersions = f.FixVersions
+
+	return theCache, nil
+}
+
 func Make(cmdName string) (*cobra.Command, error) {
 	// todo should this be a no-op to avoid accidentally triggering broken runs on malformed commands ?
 	root := &cobra.Command{
@@ -29,19 +73,93 @@ func Make(cmdName string) (*cobra.Command, error) {
 		Short:         cmdName + "is a small utility to TODO",
 		Long:          `TODO`,
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return ReadConfig(viper.GetString("config"))
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return errors.New("valid sub commands: [fetch|report|graphs|version]")
+			return errors.New("valid sub commands: [auth|fields|fetch|import|export|query|issue|epics|sprints|report|graphs|serve|daemon|version]")
 		},
 	}
 
+	root.AddCommand(&cobra.Command{
+		Use:           "auth",
+		Short:         cmdName + " authorises access to jira cloud with oauth 2.0, storing a refreshable token in --oauth-token-file",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"oauth-client-id", "oauth-client-secret", "oauth-token-file", "oauth-redirect"}),
+		RunE:          CmdAuth,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "fields [TERM...]",
+		Short:         cmdName + " lists the jira fields, their ids, names (as changelogs call them) and types, optionally only those matching terms and with values sampled from --sample issues",
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		RunE:          CmdFields,
+	})
+
 	root.AddCommand(&cobra.Command{
 		Use:           "fetch",
 		Args:          cobra.NoArgs,
 		SilenceErrors: true,
-		PreRunE:       ValidateParams([]string{"url", "user", "token", "jql", "cache"}),
+		PreRunE:       ValidateParams([]string{"cache"}),
 		RunE:          CmdFetch,
 	})
 
+	root.AddCommand(&cobra.Command{
+		Use:           "import FILE...",
+		Short:         cmdName + " imports issues from jira csv or json exports, into the import dataset unless --dataset is given",
+		Args:          cobra.MinimumNArgs(1),
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache"}),
+		RunE:          CmdImport,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "export TABLE [YYYY-MM] [YYYY-MM]",
+		Short:         cmdName + " exports issues, events, links or status-times with derived metrics as csv, jsonl or parquet, optionally limited to issues active or events in a month range",
+		Args:          cobra.RangeArgs(1, 3),
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache", "format"}),
+		RunE:          CmdExport,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "query TERM...",
+		Short:         cmdName + " lists cached issues matching terms such as type=Bug open=2026-03-01 label~azure, see Query for the full syntax",
+		Args:          cobra.MinimumNArgs(1),
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache"}),
+		RunE:          CmdQuery,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "issue KEY",
+		Short:         cmdName + " shows everything cached for one issue, its fields, events in order, time in each status and derived metrics",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache"}),
+		RunE:          CmdIssue,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "epics [KEY...]",
+		Short:         cmdName + " prints the progress of every epic by status category, or of the given epics with their issues",
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache"}),
+		RunE:          CmdEpics,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "sprints [ID...]",
+		Short:         cmdName + " prints every sprint with the issues it started with and those added or removed mid-sprint, or the issues of the given sprints",
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache"}),
+		RunE:          CmdSprints,
+	})
+
 	root.AddCommand(&cobra.Command{
 		Use:           "report [YYYY-MM] [YYYY-MM]",
 		Short:         cmdName + " calculates a report for a given month range. defaults to last month till now. single date is then to now. 2 dates is range",
@@ -52,13 +170,32 @@ func Make(cmdName string) (*cobra.Command, error) {
 	})
 
 	root.AddCommand(&cobra.Command{
-		Use:           "graphs",
+		Use:           "graphs [YYYY-MM] [YYYY-MM]",
+		Short:         cmdName + " generates graphs for a given month range. defaults to the last two years till now. single date is then to now. 2 dates is range",
 		Args:          cobra.MaximumNArgs(2),
 		SilenceErrors: true,
 		PreRunE:       ValidateParams([]string{"cache"}),
 		RunE:          CmdGraphs,
 	})
 
+	root.AddCommand(&cobra.Command{
+		Use:           "serve",
+		Short:         cmdName + " serves the generated graphs, a read only JSON API of the computed metrics and prometheus /metrics",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache", "addr"}),
+		RunE:          CmdServe,
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:           "daemon",
+		Short:         cmdName + " runs fetch on a schedule then regenerates the graphs and report, until SIGTERM",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		PreRunE:       ValidateParams([]string{"cache", "schedule"}),
+		RunE:          CmdDaemon,
+	})
+
 	// todo emoji stats/counter
 
 	root.AddCommand(&cobra.Command{
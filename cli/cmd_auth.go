@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/spf13/cobra"
+)
+
+// CmdAuth runs the oauth 2.0 authorisation code flow once, storing the token in --oauth-token-file where fetch and
+// daemon refresh it from then on
+func CmdAuth(_ *cobra.Command, _ []string) error {
+	f := GetFlags()
+
+	redirect, err := url.Parse(f.OAuth.Redirect)
+	if err != nil {
+		return fmt.Errorf("parsing oauth redirect %s: %w", f.OAuth.Redirect, err)
+	}
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		return fmt.Errorf("generating oauth state: %w", err)
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	conf := j.NewOAuthConfig(f.OAuth.ClientID, f.OAuth.ClientSecret, f.OAuth.Redirect)
+
+	// wait for atlassian to redirect the browser back to us with the code
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("state") != state:
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			errs <- fmt.Errorf("authorisation failed: %s %s", q.Get("error"), q.Get("error_description"))
+		default:
+			codes <- q.Get("code")
+		}
+		fmt.Fprintln(w, "done, you can close this window") //nolint:errcheck
+	})
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return fmt.Errorf("listening for the oauth callback on %s: %w", redirect.Host, err)
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Close()      //nolint:errcheck
+
+	c.Printf("Open this url to authorise access to jira:\n\n  <cyan>%s</>\n\nwaiting for the callback on <white>%s</>...\n", j.OAuthAuthCodeURL(conf, state), f.OAuth.Redirect)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return errors.New("timed out waiting for the oauth callback")
+	}
+
+	token, err := conf.Exchange(ctx, code)
+	if err != nil {
+		return fmt.Errorf("exchanging oauth code: %w", err)
+	}
+
+	// the token is for one or more sites, pick the one for --url or the only one
+	resources, err := j.GetAccessibleResources(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	var site *j.AccessibleResource
+	for n, r := range resources {
+		if f.Url == "" || strings.EqualFold(strings.TrimRight(r.URL, "/"), strings.TrimRight(f.Url, "/")) {
+			if site != nil {
+				return errors.New("the token has access to multiple sites, select one with --url")
+			}
+			site = &resources[n]
+		}
+	}
+	if site == nil {
+		return fmt.Errorf("the token has no access to %s", f.Url)
+	}
+
+	if err := j.SaveOAuthToken(f.OAuth.TokenFile, &j.OAuthToken{Token: *token, CloudID: site.ID, SiteURL: site.URL}); err != nil {
+		return err
+	}
+
+	c.Printf("Authorised for <cyan>%s</> (%s), token saved to <white>%s</>\n", site.Name, site.URL, f.OAuth.TokenFile)
+	return nil
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/schedule"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func CmdDaemon(_ *cobra.Command, _ []string) error {
+	f := GetFlags()
+
+	s, err := schedule.Parse(f.Schedule)
+	if err != nil {
+		return fmt.Errorf("parsing schedule %q: %w", f.Schedule, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	c.Printf("Starting daemon with schedule <white>%s</> and jitter <white>%s</>...\n", f.Schedule, f.Jitter)
+
+	var running atomic.Bool
+	var wg sync.WaitGroup
+	run := 0
+
+	for {
+		next := s.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never fires", f.Schedule)
+		}
+		if f.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(f.Jitter)))) //nolint:gosec // jitter does not need to be secure
+		}
+
+		select {
+		case <-ctx.Done():
+			c.Printf("%s <yellow>shutting down</>, waiting for any running sync to finish...\n", time.Now().Format(time.RFC3339))
+			wg.Wait()
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+
+		run++
+		if !running.CompareAndSwap(false, true) {
+			c.Printf("%s run <white>%d</> <yellow>skipped</>, previous run still in progress\n", time.Now().Format(time.RFC3339), run)
+			continue
+		}
+
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			defer running.Store(false)
+
+			started := time.Now()
+			err := RunSync(ctx, f)
+
+			if err != nil {
+				c.Printf("%s run <white>%d</> <red>failed</> after %s: %v\n", time.Now().Format(time.RFC3339), n, time.Since(started).Round(time.Millisecond), err)
+				return
+			}
+			c.Printf("%s run <white>%d</> <green>succeeded</> in %s\n", time.Now().Format(time.RFC3339), n, time.Since(started).Round(time.Millisecond))
+		}(run)
+	}
+}
+
+// RunSync fetches from jira then regenerates the graphs and report.json from the cache, for each dataset when configured.
+// once ctx is done the fetch stops between pages and nothing is regenerated
+func RunSync(ctx context.Context, f FlagData) error {
+	if err := RunFetch(ctx, f); err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+
+	from, to, err := ParseMonthRange(nil, time.Now().AddDate(-2, 0, 0))
+	if err != nil {
+		return err
+	}
+
+	if err := writeGraphsAndReport(f, GraphsPath, from, to); err != nil {
+		return err
+	}
+
+	if f.Dataset != "" || !viper.IsSet("datasets") {
+		return nil
+	}
+
+	datasets, err := GetDatasets(f)
+	if err != nil {
+		return err
+	}
+	for _, d := range datasets {
+		df := f
+		df.Dataset = d.Name
+		if err := writeGraphsAndReport(df, GraphsPath+"/"+d.Name, from, to); err != nil {
+			return fmt.Errorf("dataset %s: %w", d.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func writeGraphsAndReport(f FlagData, outPath string, from, to time.Time) error {
+	if err := RunGraphs(f, outPath, from, to); err != nil {
+		return fmt.Errorf("graphs: %w", err)
+	}
+
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+
+	weight, err := NewWeight(theCache, f.Weight)
+	if err != nil {
+		return err
+	}
+
+	r, err := BuildReport(theCache, d.StatusModel(), weight, from, to)
+	if err != nil {
+		return fmt.Errorf("building report: %w", err)
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+
+	outFile := outPath + "/report.json"
+	if err := os.WriteFile(outFile, b, 0o644); err != nil { //nolint:gosec // report is for serving
+		return fmt.Errorf("writing %s: %w", outFile, err)
+	}
+
+	return nil
+}
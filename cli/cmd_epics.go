@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	c "github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// EpicDetail is an epic's progress with its issues, output by epics when given keys
+type EpicDetail struct {
+	EpicProgress
+	Issues []EpicChild `json:"issues"`
+}
+
+type EpicChild struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Category string `json:"status_category"`
+	Summary  string `json:"summary"`
+}
+
+// CmdEpics prints the progress of every epic, or of the given epics with their issues
+func CmdEpics(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	if f.Output != "text" && f.Output != "json" {
+		return fmt.Errorf("invalid output %q, expected text or json", f.Output)
+	}
+	if f.Output == "json" {
+		c.SetOutput(os.Stderr)
+	}
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+	model := d.StatusModel()
+
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return err
+	}
+
+	categories, err := theCache.GetStatusCategories()
+	if err != nil {
+		return err
+	}
+
+	epics := FindEpics(*issues)
+
+	if len(args) == 0 {
+		progress := CalcEpicProgress(categories, model, epics)
+		if f.Output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(progress)
+		}
+
+		c.Printf("<white>%d</> epics\n", len(progress))
+		for _, p := range progress {
+			printEpicProgress(p)
+		}
+		return nil
+	}
+
+	want := map[string]bool{}
+	for _, a := range args {
+		want[strings.ToUpper(a)] = true
+	}
+
+	details := []EpicDetail{}
+	for _, e := range epics {
+		if !want[e.Key] {
+			continue
+		}
+		delete(want, e.Key)
+
+		detail := EpicDetail{EpicProgress: CalcEpicProgress(categories, model, []Epic{e})[0], Issues: []EpicChild{}}
+		for _, i := range e.Children {
+			detail.Issues = append(detail.Issues, EpicChild{
+				Key:      i.Key,
+				Type:     i.Type,
+				Status:   i.Status,
+				Category: StatusCategory(categories, model, i.Instance, i.Status),
+				Summary:  i.Summary,
+			})
+		}
+		details = append(details, detail)
+	}
+	for k := range want {
+		return fmt.Errorf("epic %s not found in %s", k, f.CachePath)
+	}
+
+	if f.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(details)
+	}
+
+	for _, detail := range details {
+		printEpicProgress(detail.EpicProgress)
+		for _, i := range detail.Issues {
+			c.Printf("      <darkGray>%-12s</> %-12s %s <darkGray>(%s)</>\n", i.Key, i.Category, i.Summary, i.Status)
+		}
+	}
+
+	return nil
+}
+
+func printEpicProgress(p EpicProgress) {
+	summary := p.Summary
+	if summary == "" {
+		summary = "<darkGray>(not cached)</>"
+	}
+	c.Printf("    <white>%-12s</> <green>%5.1f%%</> to do <lightGreen>%3d</> in progress <lightBlue>%3d</> done <green>%3d</> %s\n", p.Key, p.PercentDone, p.ToDo, p.InProgress, p.Done, summary)
+}
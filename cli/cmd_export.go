@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/parquet"
+	"github.com/spf13/cobra"
+)
+
+// the tables export can write and their columns, rows are written one at a time as they are read from the cache
+var exportTables = map[string][]parquet.Column{
+	"issues": {
+		{Name: "instance", Type: parquet.String},
+		{Name: "key", Type: parquet.String},
+		{Name: "url", Type: parquet.String},
+		{Name: "type", Type: parquet.String},
+		{Name: "status", Type: parquet.String},
+		{Name: "status_group", Type: parquet.String},
+		{Name: "resolution", Type: parquet.String},
+		{Name: "summary", Type: parquet.String},
+		{Name: "labels", Type: parquet.String},
+		{Name: "creator", Type: parquet.String},
+		{Name: "assignee", Type: parquet.String},
+		{Name: "reporter", Type: parquet.String},
+		{Name: "priority", Type: parquet.String},
+		{Name: "components", Type: parquet.String},
+		{Name: "fix_versions", Type: parquet.String},
+		{Name: "affected_versions", Type: parquet.String},
+		{Name: "parent", Type: parquet.String},
+		{Name: "created", Type: parquet.Timestamp},
+		{Name: "updated", Type: parquet.Timestamp},
+		{Name: "started", Type: parquet.Timestamp},
+		{Name: "closed", Type: parquet.Timestamp},
+		{Name: "missing", Type: parquet.Timestamp},
+		{Name: "days_open", Type: parquet.Double},
+		{Name: "lead_days", Type: parquet.Double},
+		{Name: "cycle_days", Type: parquet.Double},
+	},
+	"events": {
+		{Name: "instance", Type: parquet.String},
+		{Name: "key", Type: parquet.String},
+		{Name: "author", Type: parquet.String},
+		{Name: "date", Type: parquet.Timestamp},
+		{Name: "field", Type: parquet.String},
+		{Name: "from", Type: parquet.String},
+		{Name: "to", Type: parquet.String},
+	},
+	"links": {
+		{Name: "instance", Type: parquet.String},
+		{Name: "key", Type: parquet.String},
+		{Name: "type", Type: parquet.String},
+		{Name: "direction", Type: parquet.String},
+		{Name: "relation", Type: parquet.String},
+		{Name: "linked_key", Type: parquet.String},
+		{Name: "linked_status", Type: parquet.String},
+	},
+	"status-times": {
+		{Name: "instance", Type: parquet.String},
+		{Name: "key", Type: parquet.String},
+		{Name: "status", Type: parquet.String},
+		{Name: "status_group", Type: parquet.String},
+		{Name: "days", Type: parquet.Double},
+	},
+}
+
+func ExportTableNames() []string {
+	names := make([]string, 0, len(exportTables))
+	for n := range exportTables {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rowWriter writes rows of values in column order, nil for null
+type rowWriter interface {
+	Write(row []any) error
+	Close() error
+}
+
+func CmdExport(_ *cobra.Command, args []string) error {
+	return RunExport(GetFlags(), args)
+}
+
+// RunExport writes a table from the cache to --file or stdout, args are the table and an optional month range
+func RunExport(f FlagData, args []string) error {
+	table := args[0]
+	columns, ok := exportTables[table]
+	if !ok {
+		return fmt.Errorf("unknown table %s, expected one of %s", table, strings.Join(ExportTableNames(), ", "))
+	}
+
+	// no range exports everything
+	var from, to time.Time
+	if len(args) > 1 {
+		var err error
+		if from, to, err = ParseMonthRange(args[1:], time.Now()); err != nil {
+			return err
+		}
+	}
+
+	toStdout := f.File == "" || f.File == "-"
+	if toStdout {
+		// keep stdout for the data
+		c.SetOutput(os.Stderr)
+	}
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	// a file is written next to where it goes and only renamed into place once the export succeeds, so a failed export
+	// never leaves an empty or partial file behind
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if !toStdout {
+		if file, err = os.CreateTemp(filepath.Dir(f.File), "."+filepath.Base(f.File)+".*"); err != nil {
+			return fmt.Errorf("creating %s: %w", f.File, err)
+		}
+		defer os.Remove(file.Name()) //nolint:errcheck
+		defer file.Close()           //nolint:errcheck
+		out = file
+	}
+
+	w, err := newRowWriter(f.Format, out, columns)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	write := func(row []any) error {
+		n++
+		return w.Write(row)
+	}
+
+	switch table {
+	case "issues", "status-times":
+		err = exportIssues(theCache, d.StatusModel(), table, from, to, write)
+	case "events":
+		err = theCache.EachEvent(from, to, func(e cache.Event) error {
+			return write([]any{e.Instance, e.Key, e.Author, e.Date, e.Field, e.From, e.To})
+		})
+	case "links":
+		// links have no dates so the range doesn't apply
+		var links []cache.Link
+		if links, err = theCache.GetLinks(); err == nil {
+			for _, l := range links {
+				if err = write([]any{l.Instance, l.Key, l.Type, l.Direction, l.Relation, l.LinkedKey, l.LinkedStatus}); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", table, err)
+	}
+
+	if file != nil {
+		if err := file.Chmod(0o644); err != nil { //nolint:gosec // exports are for sharing
+			return fmt.Errorf("writing %s: %w", f.File, err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("writing %s: %w", f.File, err)
+		}
+		if err := os.Rename(file.Name(), f.File); err != nil {
+			return fmt.Errorf("writing %s: %w", f.File, err)
+		}
+	}
+
+	c.Printf("Exported <cyan>%d</> %s rows as <white>%s</>\n", n, table, f.Format)
+	return nil
+}
+
+// exportIssues writes each issue open at some point in the range with its derived metrics, or the time it spent in
+// each status
+func exportIssues(theCache *cache.Cache, model StatusModel, table string, from, to time.Time, write func([]any) error) error {
+	now := time.Now()
+
+	return theCache.EachIssue(to, func(i cache.Issue) error {
+		events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "status")
+		if err != nil {
+			return fmt.Errorf("getting events for %s: %w", i.Key, err)
+		}
+		flow := CalcIssueFlow(model, i, events)
+		if flow.Closed != nil && flow.Closed.Before(from) {
+			return nil
+		}
+
+		if table == "status-times" {
+			days := CalcTimeInStatus(flow, events, now)
+			statuses := make([]string, 0, len(days))
+			for s := range days {
+				statuses = append(statuses, s)
+			}
+			sort.Strings(statuses)
+
+			for _, s := range statuses {
+				if err := write([]any{i.Instance, i.Key, s, model.Normalise(s), days[s]}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		end := now
+		var started, closed, missing, leadDays, cycleDays any
+		if flow.Started != nil {
+			started = *flow.Started
+		}
+		if flow.Closed != nil {
+			end = *flow.Closed
+			closed = *flow.Closed
+			leadDays = flow.LeadDays()
+			if flow.Started != nil {
+				cycleDays = flow.CycleDays()
+			}
+		}
+		if i.Missing.Valid {
+			missing = i.Missing.Time
+		}
+
+		return write([]any{
+			i.Instance, i.Key, i.URL, i.Type, i.Status, model.Normalise(i.Status), i.Resolution, i.Summary,
+			strings.Join(i.Labels, ", "), i.Creator, i.Assignee, i.Reporter, i.Priority,
+			strings.Join(i.Components, ", "), strings.Join(i.FixVersions, ", "), strings.Join(i.AffectedVersions, ", "), i.Parent, i.Created, i.Updated, started, closed, missing,
+			end.Sub(i.Created).Hours() / 24, leadDays, cycleDays,
+		})
+	})
+}
+
+func newRowWriter(format string, out io.Writer, columns []parquet.Column) (rowWriter, error) {
+	switch format {
+	case "csv":
+		w := csv.NewWriter(out)
+		header := make([]string, len(columns))
+		for n, col := range columns {
+			header[n] = col.Name
+		}
+		if err := w.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvRowWriter{w: w}, nil
+	case "jsonl":
+		return &jsonlRowWriter{w: out, columns: columns}, nil
+	case "parquet":
+		return parquet.NewWriter(out, columns), nil
+	}
+
+	return nil, fmt.Errorf("unknown export format %s, expected csv, jsonl or parquet", format)
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvRowWriter) Write(row []any) error {
+	record := make([]string, len(row))
+	for n, v := range row {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			record[n] = v
+		case float64:
+			record[n] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			record[n] = v.UTC().Format(time.RFC3339)
+		default:
+			record[n] = fmt.Sprint(v)
+		}
+	}
+
+	return w.w.Write(record)
+}
+
+func (w *csvRowWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// jsonlRowWriter writes an object per line with the keys in column order
+type jsonlRowWriter struct {
+	w       io.Writer
+	columns []parquet.Column
+}
+
+func (w *jsonlRowWriter) Write(row []any) error {
+	var b strings.Builder
+	b.WriteByte('{')
+	for n, v := range row {
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC().Format(time.RFC3339)
+		}
+
+		value, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", w.columns[n].Name, err)
+		}
+		key, _ := json.Marshal(w.columns[n].Name)
+
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
+
+func (w *jsonlRowWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+// exportCache caches issues created in 2023 that are still open, closed before 2024 and closed during january 2024,
+// and one created after january 2024
+func exportCache(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+	theCache, err := cache.Open(path)
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	month := func(year int, m time.Month) time.Time {
+		return time.Date(year, m, 3, 9, 0, 0, 0, time.UTC)
+	}
+	for _, issue := range []*jiratest.Issue{
+		jiratest.NewIssue("AB-1", "To Do", month(2023, 1)).Transition(month(2023, 2), "To Do", "In Progress"),
+		jiratest.NewIssue("AB-2", "To Do", month(2023, 1)).Transition(month(2023, 3), "To Do", "Closed"),
+		jiratest.NewIssue("AB-3", "To Do", month(2023, 1)).Transition(month(2024, 1), "To Do", "Closed"),
+		jiratest.NewIssue("AB-4", "To Do", month(2024, 3)),
+	} {
+		if err := theCache.UpsertIssueFromJIRA(cache.DefaultInstance, issue.IssueScheme); err != nil {
+			t.Fatalf("caching %s: %v", issue.Key, err)
+		}
+		if _, err := theCache.UpsertEventsFromIssue(cache.DefaultInstance, issue.IssueScheme); err != nil {
+			t.Fatalf("caching %s events: %v", issue.Key, err)
+		}
+	}
+
+	return path
+}
+
+func TestExportIssuesOpenInRange(t *testing.T) {
+	t.Parallel()
+
+	f := FlagData{CachePath: exportCache(t), Format: "csv", File: filepath.Join(t.TempDir(), "issues.csv")}
+	if err := RunExport(f, []string{"issues", "2024-01", "2024-01"}); err != nil {
+		t.Fatalf("exporting: %v", err)
+	}
+
+	file, err := os.Open(f.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	var keys []string
+	for _, r := range records[1:] {
+		keys = append(keys, r[1])
+	}
+
+	// AB-1 was last updated before the range but is still open in it
+	if strings.Join(keys, ",") != "AB-1,AB-3" {
+		t.Errorf("expected the issues open during january 2024, got %v", keys)
+	}
+}
+
+func TestExportFailureLeavesNoFile(t *testing.T) {
+	t.Parallel()
+
+	path := exportCache(t)
+	dir := t.TempDir()
+
+	existing := filepath.Join(dir, "existing.csv")
+	if err := os.WriteFile(existing, []byte("kept"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, f := range map[string]FlagData{
+		"unknown format":  {CachePath: path, Format: "xlsx", File: filepath.Join(dir, "format.csv")},
+		"unknown dataset": {CachePath: path, Format: "csv", Dataset: "nope", File: filepath.Join(dir, "dataset.csv")},
+		"existing file":   {CachePath: path, Format: "xlsx", File: existing},
+	} {
+		if err := RunExport(f, []string{"issues"}); err == nil {
+			t.Errorf("%s: expected the export to fail", name)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "existing.csv" {
+		t.Errorf("expected failed exports to leave no files, got %v", entries)
+	}
+	if b, err := os.ReadFile(existing); err != nil || string(b) != "kept" {
+		t.Errorf("expected a failed export to leave the existing file, got %q: %v", b, err)
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -13,7 +14,21 @@ import (
 )
 
 func CmdFetch(_ *cobra.Command, _ []string) error {
-	f := GetFlags()
+	return RunFetch(context.Background(), GetFlags())
+}
+
+// RunFetch retrieves all issues matching each dataset's jql and upserts them and their events into the cache, stopping
+// between pages once ctx is done
+func RunFetch(ctx context.Context, f FlagData) error {
+	datasets, err := GetDatasets(f)
+	if err != nil {
+		return err
+	}
+
+	instances, err := GetInstances(f)
+	if err != nil {
+		return err
+	}
 
 	// open cache
 	cache, err := cache.Open(f.CachePath)
@@ -22,17 +37,60 @@ func CmdFetch(_ *cobra.Command, _ []string) error {
 	}
 	defer cache.DB.Close() //nolint:errcheck
 
-	i := j.NewInstance(f.Url, f.User, f.Token)
+	started := time.Now()
 
-	c.Printf("Retrieving all issues matching <white>%s</> from <cyan>%s</>...\n", f.JQL, f.Url)
-	c.Printf("  Fields %s\n", strings.Join(f.Fields, ", "))
-	c.Printf("  Expand %s\n", strings.Join(f.Expand, ", "))
+	n := 0
+	for _, d := range datasets {
+		if d.JQL == "" {
+			err = fmt.Errorf("dataset %s has no jql", d.Name)
+			break
+		}
+
+		var inst *j.Instance
+		if inst, err = GetInstance(instances, d.Instance); err != nil {
+			err = fmt.Errorf("dataset %s: %w", d.Name, err)
+			break
+		}
+
+		var count int
+		count, err = fetchDataset(ctx, cache, *inst, d, f)
+		n += count
+		if err != nil {
+			break
+		}
+	}
+
+	// record how the run went for the metrics exporter
+	if serr := cache.InsertSync(started, time.Since(started), n, err); serr != nil {
+		c.Printf("<red>failed to record sync:</> %v\n", serr)
+	}
+
+	return err
+}
+
+func fetchDataset(ctx context.Context, cache *cache.Cache, inst j.Instance, d Dataset, f FlagData) (int, error) {
+	started := time.Now()
+
+	c.Printf("Retrieving all <white>%s</> issues matching <white>%s</> from <cyan>%s</>...\n", d.Name, d.JQL, inst.URL)
+
+	inst = discoverCustomFields(inst)
+	for _, nf := range inst.NumberFields {
+		if err := cache.UpsertNumberField(inst.Name, nf.Name, nf.ID, nf.FieldName); err != nil {
+			return 0, err
+		}
+	}
 
 	n := 0
-	err = i.ListAllIssues(f.JQL, &f.Fields, &f.Expand, func(results *models.IssueSearchScheme, resp *models.ResponseScheme) error {
+	seen := map[string]bool{}
+	err := inst.ListAllIssues(d.JQL, func(results *models.IssueSearchScheme, custom j.CustomFields) error {
+		// a partial page is fine to cache, the next run fetches the rest
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		c.Printf("<magenta>%d</>-<lightMagenta>%d</> <darkGray>of %d</>\n", results.StartAt, results.MaxResults, results.Total)
 		for _, i := range results.Issues {
 			n++
+			seen[i.Key] = true
 
 			if i.Fields == nil {
 				c.Printf("<darkGray>%03d/%d</> <lightGreen>%s</> - (no fields returned)\n", n, results.Total, i.Key)
@@ -59,15 +117,14 @@ func CmdFetch(_ *cobra.Command, _ []string) error {
 			}
 
 			c.Printf("<darkGray>%03d/%d</> <%s>%s</><darkGray>@%s</> - %s\n", n, results.Total, keyColour, i.Key, parsedDate.Format("2006-01-02"), i.Fields.Summary)
-			if err = cache.UpsertIssueFromJIRA(i); err != nil {
-				return fmt.Errorf("cache issue upsert failed: %w", err)
-			}
-
-			count, err := cache.UpsertEventsFromIssue(i)
+			count, aliases, err := upsertIssue(cache, inst, []string{d.Name}, i, custom[i.Key])
 			if err != nil {
-				return fmt.Errorf("cache issue events upsert failed: %w", err)
+				return err
+			}
+			c.Printf("    <darkGray>by </>%s, <%s>%s</> with <cyan>%d</> new events\n", creatorName, keyColour, statusName, count)
+			for _, a := range aliases {
+				c.Printf("    <darkGray>previously </>%s<darkGray>, moved %s</>\n", a.Alias, a.Date.Format("2006-01-02"))
 			}
-			c.Printf("    <darkGray>by </>%s, <%s>%s</> with <cyan>%d</> events\n", creatorName, keyColour, statusName, *count)
 
 			// if closed get events for status and find the date of the last one which is "closed" and update the issue with days open and "closed" date
 			// todo, we don't care about this yet & given the low issue count ( < 1000, we can just parse all events for all issues when reporting and generating graphs)
@@ -75,7 +132,203 @@ func CmdFetch(_ *cobra.Command, _ []string) error {
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to list issues for %s @ %s: %w", i.URL, f.JQL, err)
+		return n, fmt.Errorf("failed to list issues for %s @ %s: %w", inst.URL, d.JQL, err)
+	}
+
+	fetchVersions(cache, inst, seen)
+	fetchStatuses(cache, inst)
+	fetchSprints(cache, inst, seen)
+
+	// only a complete fetch tells us what is no longer returned by the jql
+	return n, tombstoneMissing(cache, d, seen, started)
+}
+
+// upsertIssue caches an issue with its custom fields, events and aliases and links it to the datasets. fetch and
+// webhooks both go through it so an issue ends up the same however it arrived
+func upsertIssue(theCache *cache.Cache, inst j.Instance, datasets []string, i *models.IssueScheme, custom *j.IssueCustomFields) (int, []cache.IssueAlias, error) {
+	if err := theCache.UpsertIssueFromJIRA(inst.Name, i); err != nil {
+		return 0, nil, fmt.Errorf("cache issue upsert failed: %w", err)
+	}
+	for _, d := range datasets {
+		if err := theCache.LinkIssueToDataset(d, inst.Name, i.Key); err != nil {
+			return 0, nil, err
+		}
+	}
+	if custom != nil && inst.SprintField != "" {
+		if err := theCache.UpsertIssueSprints(inst.Name, i.Key, custom.Sprints); err != nil {
+			return 0, nil, err
+		}
+	}
+	if custom != nil && len(inst.NumberFields) > 0 {
+		if err := theCache.UpsertIssueNumbers(inst.Name, i.Key, custom.Numbers); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	count, err := theCache.UpsertEventsFromIssue(inst.Name, i)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cache issue events upsert failed: %w", err)
+	}
+
+	aliases, err := theCache.UpsertAliasesFromIssue(inst.Name, i)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cache issue aliases upsert failed: %w", err)
+	}
+
+	return *count, aliases, nil
+}
+
+// fetchVersions refreshes the versions and their release dates of every project issues were fetched from. versions
+// only add release dates to reports so failing to get them is a warning rather than failing the fetch
+func fetchVersions(theCache *cache.Cache, inst j.Instance, seen map[string]bool) {
+	for project := range issueProjects(seen) {
+		versions, err := inst.GetProjectVersions(project)
+		if err != nil {
+			c.Printf("<yellow>failed to get versions for %s:</> %v\n", project, err)
+			continue
+		}
+
+		if err := theCache.UpsertProjectVersions(inst.Name, project, versions); err != nil {
+			c.Printf("<yellow>failed to cache versions for %s:</> %v\n", project, err)
+			continue
+		}
+		c.Printf("Cached <cyan>%d</> versions of <white>%s</>\n", len(versions), project)
+	}
+}
+
+// discoverCustomFields looks up the classic Epic Link, sprint and story points custom fields when they aren't
+// configured, and the names of number fields their changelogs use. sites without an epic link use the parent field and
+// ones without jira software have no sprints or story points, so failing to find them is a warning rather than failing
+// the fetch
+func discoverCustomFields(inst j.Instance) j.Instance {
+	storyPoints := false
+	named := true
+	for _, f := range inst.NumberFields {
+		storyPoints = storyPoints || f.Name == j.StoryPoints
+		named = named && f.FieldName != ""
+	}
+	if inst.EpicLinkField != "" && inst.SprintField != "" && storyPoints && named {
+		return inst
+	}
+
+	fields, err := inst.GetFields()
+	if err != nil {
+		c.Printf("<yellow>failed to get fields to find the epic link, sprint and number fields:</> %v\n", err)
+		return inst
+	}
+
+	if inst.EpicLinkField == "" {
+		inst.EpicLinkField = j.FindCustomField(fields, j.EpicLinkSchema)
+	}
+	if inst.SprintField == "" {
+		inst.SprintField = j.FindCustomField(fields, j.SprintSchema)
+	}
+	if !storyPoints {
+		id := j.FindCustomField(fields, j.StoryPointsSchema)
+		if id == "" {
+			id = j.FindFieldByName(fields, j.StoryPointsFieldNames...)
+		}
+		if id != "" {
+			inst.NumberFields = append(append([]j.NumberField{}, inst.NumberFields...), j.NumberField{Name: j.StoryPoints, ID: id})
+		}
+	}
+	numberFields := make([]j.NumberField, 0, len(inst.NumberFields))
+	for _, f := range inst.NumberFields {
+		f.FieldName = j.FieldName(fields, f.ID)
+		if f.FieldName == "" {
+			c.Printf("<yellow>number field %s is %s which the instance doesn't have</>\n", f.Name, f.ID)
+		}
+		numberFields = append(numberFields, f)
+	}
+	inst.NumberFields = numberFields
+
+	if inst.EpicLinkField != "" {
+		c.Printf("  Epic Link %s\n", inst.EpicLinkField)
+	}
+	if inst.SprintField != "" {
+		c.Printf("  Sprint %s\n", inst.SprintField)
+	}
+	for _, f := range inst.NumberFields {
+		c.Printf("  %s %s <darkGray>(%s)</>\n", f.Name, f.ID, f.FieldName)
+	}
+	return inst
+}
+
+// fetchSprints refreshes the scrum boards of every project issues were fetched from and all their sprints. sprints
+// only add dates to the sprint field's, so failing to get them is a warning rather than failing the fetch
+func fetchSprints(theCache *cache.Cache, inst j.Instance, seen map[string]bool) {
+	if inst.SprintField == "" {
+		return
+	}
+
+	for project := range issueProjects(seen) {
+		boards, err := inst.GetScrumBoards(project)
+		if err != nil {
+			c.Printf("<yellow>failed to get boards for %s:</> %v\n", project, err)
+			continue
+		}
+
+		for _, b := range boards {
+			if err := theCache.UpsertBoard(inst.Name, project, b); err != nil {
+				c.Printf("<yellow>failed to cache board %d:</> %v\n", b.ID, err)
+				continue
+			}
+
+			sprints, err := inst.GetBoardSprints(b.ID)
+			if err != nil {
+				c.Printf("<yellow>failed to get sprints for board %d:</> %v\n", b.ID, err)
+				continue
+			}
+			if err := theCache.UpsertBoardSprints(inst.Name, b.ID, sprints); err != nil {
+				c.Printf("<yellow>failed to cache sprints for board %d:</> %v\n", b.ID, err)
+				continue
+			}
+			c.Printf("Cached <cyan>%d</> sprints of <white>%s</> board <white>%s</>\n", len(sprints), project, b.Name)
+		}
+	}
+}
+
+// fetchStatuses refreshes the category (To Do, In Progress or Done) of every status, used to roll up epic progress.
+// statuses without one fall back to the status model so failing to get them is a warning rather than failing the fetch
+func fetchStatuses(theCache *cache.Cache, inst j.Instance) {
+	statuses, err := inst.GetStatuses()
+	if err != nil {
+		c.Printf("<yellow>failed to get statuses:</> %v\n", err)
+		return
+	}
+
+	if err := theCache.UpsertStatuses(inst.Name, statuses); err != nil {
+		c.Printf("<yellow>failed to cache statuses:</> %v\n", err)
+		return
+	}
+	c.Printf("Cached <cyan>%d</> statuses\n", len(statuses))
+}
+
+// issueProjects are the projects of issue keys
+func issueProjects(keys map[string]bool) map[string]bool {
+	projects := map[string]bool{}
+	for key := range keys {
+		if n := strings.LastIndex(key, "-"); n > 0 {
+			projects[key[:n]] = true
+		}
+	}
+	return projects
+}
+
+// tombstoneMissing marks cached issues the jql no longer returns (deleted or moved projects) as missing and reports them
+func tombstoneMissing(theCache *cache.Cache, d Dataset, seen map[string]bool, at time.Time) error {
+	tombstoned, err := theCache.TombstoneDatasetIssuesNotIn(d.Name, d.Instance, seen, at)
+	if err != nil {
+		return fmt.Errorf("tombstoning missing issues: %w", err)
+	}
+
+	if len(tombstoned) == 0 {
+		return nil
+	}
+
+	c.Printf("<yellow>%d</> cached issues no longer returned by the <white>%s</> jql, marked as missing:\n", len(tombstoned), d.Name)
+	for _, i := range tombstoned {
+		c.Printf("  <yellow>%s</> %s <darkGray>(%s)</>\n", i.Key, i.Summary, i.Status)
 	}
 
 	return nil
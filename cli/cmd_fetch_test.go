@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+var created = time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+
+func TestFetchIntoCache(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.ChangelogLimit = 1
+
+	s.AddIssues(
+		jiratest.NewIssue("AB-1", "To Do", created).Component("api").
+			Transition(created.Add(time.Hour), "To Do", "In Progress").
+			Transition(created.Add(2*time.Hour), "In Progress", "Closed"),
+		jiratest.NewIssue("AB-2", "To Do", created.Add(24*time.Hour)).Parent("AB-1"),
+	)
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+	f := FlagData{Url: s.URL, User: jiratest.User, Token: jiratest.Token, JQL: "project = AB", CachePath: path}
+	if err := RunFetch(context.Background(), f); err != nil {
+		t.Fatalf("fetching: %v", err)
+	}
+
+	theCache, err := cache.Open(path)
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		t.Fatalf("reading issues: %v", err)
+	}
+	if len(*issues) != 2 {
+		t.Fatalf("expected 2 cached issues, got %d", len(*issues))
+	}
+
+	one, err := theCache.GetIssue(cache.DefaultInstance, "AB-1")
+	if err != nil {
+		t.Fatalf("reading AB-1: %v", err)
+	}
+	if one.Status != "Closed" || !one.Created.Equal(created) || len(one.Components) != 1 || one.Components[0] != "api" {
+		t.Errorf("unexpected AB-1 %+v", one)
+	}
+
+	two, err := theCache.GetIssue(cache.DefaultInstance, "AB-2")
+	if err != nil {
+		t.Fatalf("reading AB-2: %v", err)
+	}
+	if two.Parent != "AB-1" {
+		t.Errorf("expected AB-2 to have parent AB-1, got %q", two.Parent)
+	}
+
+	// search only returned the last history, the first comes from completing the changelog
+	events, err := theCache.GetIssueEvents(cache.DefaultInstance, "AB-1")
+	if err != nil {
+		t.Fatalf("reading AB-1 events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events for AB-1, got %d", len(events))
+	}
+	if e := events[0]; e.Field != "status" || e.From != "To Do" || e.To != "In Progress" || !e.Date.Equal(created.Add(time.Hour)) {
+		t.Errorf("unexpected first event %+v", e)
+	}
+
+	datasets, err := theCache.GetDatasets()
+	if err != nil {
+		t.Fatalf("reading datasets: %v", err)
+	}
+	if len(datasets) != 1 || datasets[0] != cache.DefaultDataset {
+		t.Errorf("expected the issues in the default dataset, got %v", datasets)
+	}
+}
+
+func TestFetchStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.PageSize = 1
+
+	s.AddIssues(
+		jiratest.NewIssue("AB-1", "To Do", created),
+		jiratest.NewIssue("AB-2", "To Do", created),
+		jiratest.NewIssue("AB-3", "To Do", created),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+	f := FlagData{Url: s.URL, User: jiratest.User, Token: jiratest.Token, JQL: "project = AB", CachePath: path}
+	if err := RunFetch(ctx, f); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the fetch to be cancelled, got %v", err)
+	}
+
+	searches := 0
+	for _, r := range s.Requests() {
+		if r == "POST /rest/api/3/search/jql" {
+			searches++
+		}
+	}
+	if searches != 1 {
+		t.Errorf("expected the fetch to stop after the first page, got %d searches", searches)
+	}
+
+	theCache, err := cache.Open(path)
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		t.Fatalf("reading issues: %v", err)
+	}
+	if len(*issues) != 0 {
+		t.Errorf("expected nothing to be cached, got %d issues", len(*issues))
+	}
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/spf13/cobra"
+)
+
+// FieldSamplesMax is how many distinct values are shown for each field when sampling
+const FieldSamplesMax = 3
+
+// FieldInfo is a jira field as the fields command lists it
+type FieldInfo struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"` // what changelogs call the field
+	Type        string   `json:"type,omitempty"`
+	Custom      bool     `json:"custom"`
+	CustomType  string   `json:"custom_type,omitempty"` // such as com.pyxis.greenhopper.jira:gh-sprint
+	ClauseNames []string `json:"clause_names,omitempty"`
+	Samples     []string `json:"samples,omitempty"`
+}
+
+// CmdFields lists the fields of a jira instance so the ids for --number-field, --sprint-field and the like don't have to
+// be guessed, optionally only those whose id or name contain any of the terms and with values sampled from issues
+func CmdFields(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	if f.Output != "text" && f.Output != "json" {
+		return fmt.Errorf("invalid output %q, expected text or json", f.Output)
+	}
+	if f.Output == "json" {
+		c.SetOutput(os.Stderr)
+	}
+
+	inst, jql, err := fieldsInstance(f)
+	if err != nil {
+		return err
+	}
+
+	c.Printf("Retrieving fields from <cyan>%s</>...\n", inst.URL)
+	fields, err := inst.GetFields()
+	if err != nil {
+		return fmt.Errorf("getting fields: %w", err)
+	}
+
+	var samples []map[string]json.RawMessage
+	if f.Sample > 0 {
+		if jql == "" {
+			return errors.New("sampling values requires a jql, from --jql or --dataset")
+		}
+		c.Printf("Sampling <white>%d</> issues matching <white>%s</>...\n", f.Sample, jql)
+		if samples, err = inst.SampleFields(jql, f.Sample); err != nil {
+			return fmt.Errorf("sampling fields: %w", err)
+		}
+	}
+
+	infos := []FieldInfo{}
+	for _, field := range fields {
+		if !matchesAnyTerm(args, field.ID, field.Name) {
+			continue
+		}
+		infos = append(infos, NewFieldInfo(field, samples))
+	}
+	sort.Slice(infos, func(a, b int) bool {
+		if infos[a].Custom != infos[b].Custom {
+			return !infos[a].Custom
+		}
+		return strings.ToLower(infos[a].Name) < strings.ToLower(infos[b].Name)
+	})
+
+	if f.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // types such as array<string>
+		return enc.Encode(infos)
+	}
+
+	c.Printf("<white>%d</> fields\n", len(infos))
+	c.Printf("  <darkGray>%-24s %-32s %-16s %s</>\n", "id", "name (in changelogs)", "type", "custom type")
+	for _, i := range infos {
+		custom := ""
+		if i.Custom {
+			custom = "custom " + i.CustomType
+		}
+		c.Printf("  <white>%-24s</> %-32s <cyan>%-16s</> <darkGray>%s</>\n", i.ID, i.Name, i.Type, custom)
+		for _, s := range i.Samples {
+			c.Printf("      <darkGray>%s</>\n", s)
+		}
+	}
+
+	return nil
+}
+
+// fieldsInstance is the instance to list the fields of, from --instance, --dataset or the only one there is, with the
+// jql to sample values with
+func fieldsInstance(f FlagData) (*j.Instance, string, error) {
+	instances, err := GetInstances(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name, jql := f.Instance, f.JQL
+	if f.Dataset != "" {
+		d, err := GetDataset(f)
+		if err != nil {
+			return nil, "", err
+		}
+		name, jql = d.Instance, d.JQL
+	}
+
+	if name == "" {
+		if len(instances) != 1 {
+			return nil, "", errors.New("several instances are configured, pick one with --instance or --dataset")
+		}
+		return &instances[0], jql, nil
+	}
+
+	inst, err := GetInstance(instances, name)
+	return inst, jql, err
+}
+
+// matchesAnyTerm is true with no terms or when any value contains any of them, ignoring case
+func matchesAnyTerm(terms []string, values ...string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	for _, t := range terms {
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(v), strings.ToLower(t)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewFieldInfo describes a field with up to FieldSamplesMax distinct values it has in the samples
+func NewFieldInfo(field *models.IssueFieldScheme, samples []map[string]json.RawMessage) FieldInfo {
+	info := FieldInfo{
+		ID:          field.ID,
+		Name:        field.Name,
+		Custom:      field.Custom,
+		ClauseNames: field.ClauseNames,
+	}
+	if field.Schema != nil {
+		info.Type = field.Schema.Type
+		if field.Schema.Type == "array" && field.Schema.Items != "" {
+			info.Type += "<" + field.Schema.Items + ">"
+		}
+		info.CustomType = field.Schema.Custom
+	}
+
+	seen := map[string]bool{}
+	for _, s := range samples {
+		v := sampleValue(s[field.ID])
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		if info.Samples = append(info.Samples, v); len(info.Samples) == FieldSamplesMax {
+			break
+		}
+	}
+
+	return info
+}
+
+// sampleValue is a short description of a field value, objects by their name or value when they have one, empty for
+// unset fields
+func sampleValue(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" || string(raw) == "[]" || string(raw) == `""` {
+		return ""
+	}
+
+	describe := func(raw json.RawMessage) string {
+		var o map[string]any
+		if json.Unmarshal(raw, &o) == nil {
+			for _, k := range []string{"displayName", "name", "value", "key"} {
+				if s, ok := o[k].(string); ok && s != "" {
+					return s
+				}
+			}
+		}
+		return string(raw)
+	}
+
+	v := describe(raw)
+	var items []json.RawMessage
+	if json.Unmarshal(raw, &items) == nil {
+		names := make([]string, 0, len(items))
+		for _, i := range items {
+			names = append(names, describe(i))
+		}
+		v = "[" + strings.Join(names, ", ") + "]"
+	}
+
+	// by rune so names aren't cut mid character
+	if r := []rune(v); len(r) > 80 {
+		v = string(r[:77]) + "..."
+	}
+	return v
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSampleValueTruncatesByRune(t *testing.T) {
+	t.Parallel()
+
+	long, _ := json.Marshal(strings.Repeat("é", 100))
+
+	v := sampleValue(long)
+	if !utf8.ValidString(v) {
+		t.Fatalf("expected valid utf-8, got %q", v)
+	}
+	if n := utf8.RuneCountInString(v); n != 80 || !strings.HasSuffix(v, "...") {
+		t.Errorf("expected 77 characters and ..., got %d: %q", n, v)
+	}
+
+	if v := sampleValue(json.RawMessage(`[{"name":"Sprint 1"},{"name":"Sprint 2"}]`)); v != "[Sprint 1, Sprint 2]" {
+		t.Errorf("expected the list of names, got %q", v)
+	}
+}
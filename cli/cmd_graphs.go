@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,13 +15,21 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// todo add to flags
+const GraphsPath = "graphs"
+
 func CmdGraphs(_ *cobra.Command, args []string) error {
-	var err error
-	f := GetFlags()
+	// default to past two years
+	from, to, err := ParseMonthRange(args, time.Now().AddDate(-2, 0, 0))
+	if err != nil {
+		return err
+	}
 
-	// todo add to flags
-	outPath := "graphs"
+	return RunGraphs(GetFlags(), GraphsPath, from, to)
+}
 
+// RunGraphs renders all graphs for the date range into outPath
+func RunGraphs(f FlagData, outPath string, from, to time.Time) error {
 	// ensure path exists
 	if _, err := os.Stat(outPath); os.IsNotExist(err) {
 		err := os.MkdirAll(outPath, os.ModePerm) //nolint:gosec // CLI tool, not a security concern
@@ -29,46 +38,54 @@ func CmdGraphs(_ *cobra.Command, args []string) error {
 		}
 	}
 
-	// default to past year
-	from := time.Now().AddDate(-2, 0, 0)
-	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
-	to := time.Now()
-
-	aragc := len(args)
-	if aragc > 1 {
-		from, err = time.Parse("2006-01", args[0])
-		if err != nil {
-			return fmt.Errorf("failed to parse time %s : %w", args[0], err)
-		}
+	// open cache
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
 
-		if aragc == 2 {
-			to, err = time.Parse("2006-01", args[1])
-			if err != nil {
-				return fmt.Errorf("failed to parse time %s : %w", args[1], err)
-			}
-		}
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
 	}
 
-	// open cache
-	cache, err := cache.Open(f.CachePath)
+	weight, err := NewWeight(theCache, f.Weight)
 	if err != nil {
-		return fmt.Errorf("opening cache %s: %w", f.CachePath, err)
+		return err
 	}
-	defer cache.DB.Close() //nolint:errcheck
 
 	c.Printf("Generating graphs for issues from <white>%s</> to <white>%s</>...\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
-	if err = GraphRepoOpenIssuesDaily(cache, outPath, from, to); err != nil {
+	if err = GraphRepoOpenIssuesDaily(theCache, *d, weight, outPath, from, to); err != nil {
 		return fmt.Errorf("failed to generate daily open pr graphs path: %w", err)
 	}
+	if err = GraphOpenIssuesByLabelDaily(theCache, *d, outPath, from, to); err != nil {
+		return fmt.Errorf("failed to generate daily open by label graph: %w", err)
+	}
+	if err = GraphOpenIssuesBy(theCache, *d, outPath, "assignee", func(i cache.Issue) []string { return []string{AssigneeName(i.Assignee)} }); err != nil {
+		return fmt.Errorf("failed to generate open by assignee graph: %w", err)
+	}
+	if err = GraphOpenIssuesBy(theCache, *d, outPath, "priority", func(i cache.Issue) []string { return []string{PriorityName(i.Priority)} }); err != nil {
+		return fmt.Errorf("failed to generate open by priority graph: %w", err)
+	}
+	if err = GraphOpenIssuesBy(theCache, *d, outPath, "component", ComponentNames); err != nil {
+		return fmt.Errorf("failed to generate open by component graph: %w", err)
+	}
+	if err = GraphOpenIssuesBy(theCache, *d, outPath, "fix-version", FixVersionNames); err != nil {
+		return fmt.Errorf("failed to generate open by fix version graph: %w", err)
+	}
+	if err = GraphEpicBurnUps(theCache, *d, weight, outPath, from, to); err != nil {
+		return fmt.Errorf("failed to generate epic burn-up graphs: %w", err)
+	}
+	if err = GraphBlockers(theCache, *d, outPath); err != nil {
+		return fmt.Errorf("failed to generate blockers graph: %w", err)
+	}
+	if err = GraphSprintVelocity(theCache, *d, weight, outPath, from, to); err != nil {
+		return fmt.Errorf("failed to generate sprint velocity graphs: %w", err)
+	}
 	return nil
 }
 
-type DailyOpenIssues struct {
-	Date     time.Time
-	Total    int
-	Statuses map[string]int // jira status are freeform, so lets just allow any
-}
-
 func colorizeStatus(status string) string {
 	switch status {
 	case "Closed":
@@ -96,7 +113,7 @@ func colorizeStatus(status string) string {
 	}
 }
 
-func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to time.Time) error {
+func GraphRepoOpenIssuesDaily(theCache *cache.Cache, d Dataset, w *Weight, outPath string, from, to time.Time) error {
 	c.Printf("\n  📊 Issues open daily (stacked area)\n")
 
 	// for now lets just go over ALL issues until we can query for any open within a date
@@ -106,22 +123,9 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 	}
 	c.Printf("    Loaded <white>%d</> issues from cache\n", len(*issues))
 
-	// defined statuses for the graph (in stack order)
-	allStatuses := []string{"Other", "Accepted", "Awaiting Prioritisation", "Pending Triage", "Blocked", "Needs More Info", "To Do", "Prioritised", "In Progress", "In Review"}
-	statusLookupMap := map[string]bool{}
-	for _, status := range allStatuses {
-		statusLookupMap[status] = true
-	}
-
-	// status mappings to normalise variations
-	statusMappings := map[string]string{
-		"In Development":             "In Progress",
-		"Accepted":                   "To Do",
-		"Need More Information":      "Needs More Info",
-		"R&D to Investigate Further": "Awaiting Prioritisation",
-		"Security Triage":            "Pending Triage",
-		"Under Review by R&D PM":     "In Review",
-	}
+	model := d.StatusModel()
+	allStatuses := model.Statuses
+	statusMappings := model.Mappings
 
 	c.Printf("    Statuses: ")
 	for i, s := range allStatuses {
@@ -163,7 +167,7 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 		mapped := ""
 		if m, ok := statusMappings[s]; ok {
 			mapped = c.Sprintf(" <darkGray>→</> %s", colorizeStatus(m))
-		} else if !statusLookupMap[s] && s != "Closed" {
+		} else if !model.Has(s) && s != "Closed" {
 			mapped = c.Sprintf(" <darkGray>→</> <yellow>Other</>")
 		}
 		c.Printf("      <darkGray>%4d</> %s%s\n", allFoundStatuses[s], colorizeStatus(s), mapped)
@@ -173,112 +177,17 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 	days := int(to.Sub(from).Hours()/24) + 2
 	c.Printf("    Generating data for <white>%d</> days (<white>%s</> to <white>%s</>)\n", days, from.Format("2006-01-02"), to.Format("2006-01-02"))
 
-	dates := map[string]DailyOpenIssues{}
-	for day := from.AddDate(0, 0, -1); day.Before(to.AddDate(0, 0, 1)); day = day.AddDate(0, 0, 1) {
-		k := day.Format("2006-01-02")
-		dates[k] = DailyOpenIssues{
-			Date:     day,
-			Statuses: map[string]int{},
-		}
-
-		for _, status := range allStatuses {
-			dates[k].Statuses[status] = 0
-		}
-	}
-
 	// process each issue, replay events day by day
 	c.Printf("    Processing <white>%d</> issues...\n", len(*issues))
-	skippedAfterTo := 0
-	closedCount := 0
-	otherCount := 0
-
-	for _, i := range *issues {
-		opened := time.Date(i.Created.Year(), i.Created.Month(), i.Created.Day(), 0, 0, 0, 0, time.UTC)
-
-		if opened.After(to) {
-			skippedAfterTo++
-			continue
-		}
-
-		// figure out timeline of events that matter
-		events, err := theCache.GetIssueEventsForField(i.Key, "status")
-		if err != nil {
-			return fmt.Errorf("getting events for %s: %w", i.Key, err)
-		}
-
-		// figure out initial status before any events
-		status := "Other"
-		if len(events) == 0 {
-			// no events, use current status (unless closed)
-			if i.Status != "Closed" {
-				status = i.Status
-			}
-		} else {
-			// use the first event's From field as the initial status
-			status = events[0].From
-		}
-		// apply mappings and fallback to Other
-		if s, ok := statusMappings[status]; ok {
-			status = s
-		}
-		if status != "Closed" && !statusLookupMap[status] {
-			status = "Other"
-		}
-
-		// for each day from open to closed (or now) count this issue using the above array to figure out its "state"
-		// by playing back events to "set the state" until the events
-		eventIndex := 0
-		for day := opened; ; day = day.AddDate(0, 0, 1) {
-			// go through all events for "today" and set the status
-			if len(events) > 0 {
-				for ; eventIndex < len(events) && events[eventIndex].Date.Before(day.AddDate(0, 0, 1)); eventIndex++ {
-					status = events[eventIndex].To
-					if status == "Closed" {
-						break
-					}
-
-					if s, ok := statusMappings[status]; ok {
-						status = s
-					}
-
-					// any status not in our list goes to Other
-					if !statusLookupMap[status] {
-						status = "Other"
-					}
-				}
-			}
-
-			// skip data before the "from" date, but here so status is updated
-			if day.Before(from.AddDate(0, 0, -1)) {
-				continue
-			}
-
-			k := day.Format("2006-01-02")
-			dayData := dates[k]
-			dayData.Total++
-			dayData.Statuses[status]++
-			dates[k] = dayData
-
-			if day.After(to.AddDate(0, 0, -1)) {
-				break
-			}
-
-			// if closed we're done
-			if status == "Closed" {
-				closedCount++
-				break
-			}
-		}
-
-		if status == "Other" {
-			otherCount++
-		}
+	daily, err := CalcDailyOpenIssues(theCache, model, w, *issues, from, to)
+	if err != nil {
+		return fmt.Errorf("calculating daily open issues: %w", err)
 	}
 
-	c.Printf("      <darkGray>Skipped %d issues created after range</>\n", skippedAfterTo)
-	c.Printf("      <darkGray>%d issues closed within range</>\n", closedCount)
-	if otherCount > 0 {
-		c.Printf("      <yellow>%d</> issues with unmapped status (shown as Other)\n", otherCount)
+	c.Printf("      <darkGray>Skipped %d issues created after range</>\n", daily.SkippedAfterTo)
+	c.Printf("      <darkGray>%d issues closed within range</>\n", daily.ClosedCount)
+	if daily.OtherCount > 0 {
+		c.Printf("      <yellow>%d</> issues with unmapped status (shown as Other)\n", daily.OtherCount)
 	}
 
 	var xAxis []string
@@ -288,24 +197,8 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 		lineDataMap[status] = []opts.LineData{}
 	}
 
-	sortedDays := make([]string, 0, len(dates))
-	for day := range dates {
-		sortedDays = append(sortedDays, day)
-	}
-	sort.Strings(sortedDays)
-
-	for _, date := range sortedDays {
-		day := dates[date]
-
-		if day.Date.Before(from.AddDate(0, 0, -1)) {
-			continue
-		}
-
-		if day.Date.After(to) {
-			continue
-		}
-
-		xAxis = append(xAxis, date)
+	for _, day := range daily.Days {
+		xAxis = append(xAxis, day.Date.Format("2006-01-02"))
 		for _, status := range allStatuses {
 			lineDataMap[status] = append(lineDataMap[status], opts.LineData{Value: day.Statuses[status]})
 		}
@@ -313,12 +206,17 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 
 	c.Printf("    Rendering chart with <white>%d</> data points across <white>%d</> series...\n", len(xAxis), len(allStatuses))
 
+	title := "Azure Team JIRAs Open (daily)"
+	if d.Name != "" {
+		title = d.Name + " JIRAs Open (daily)"
+	}
+
 	// render graph
 	graph := charts.NewLine()
 	graph.SetGlobalOptions(
 		// charts.WithInitializationOpts(opts.Initialization{Theme: types.ThemeWesteros}),
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Azure Team JIRAs Open (daily)",
+			Title:    title,
 			Subtitle: "By Status: " + strings.Join(allStatuses, ", "),
 			Left:     "center", // nolint:misspell
 		}),
@@ -328,7 +226,7 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 			// AxisLabel: &opts.AxisLabel{Show: true, Formatter: "{value} x-unit"},
 		}),
 		charts.WithYAxisOpts(opts.YAxis{
-			Name: "# Issues",
+			Name: w.Unit(),
 			// AxisLabel: &opts.AxisLabel{Show: true, Formatter: "{value} x-unit"},
 		}),
 		charts.WithInitializationOpts(opts.Initialization{
@@ -389,3 +287,608 @@ func GraphRepoOpenIssuesDaily(theCache *cache.Cache, outPath string, from, to ti
 
 	return nil
 }
+
+// GraphLabelsMax is how many of the most used labels are graphed over time, more makes the chart unreadable
+const GraphLabelsMax = 10
+
+// GraphOpenIssuesByLabelDaily renders the number of open issues with each of the most used labels day by day, an issue
+// is counted under every label it had that day so the lines are not stacked
+func GraphOpenIssuesByLabelDaily(theCache *cache.Cache, d Dataset, outPath string, from, to time.Time) error {
+	c.Printf("\n  📊 Issues open by label daily (line)\n")
+
+	counts, err := theCache.GetLabels()
+	if err != nil {
+		return err
+	}
+
+	labels := make([]string, 0, len(counts))
+	for l := range counts {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(a, b int) bool {
+		if counts[labels[a]] != counts[labels[b]] {
+			return counts[labels[a]] > counts[labels[b]]
+		}
+		return labels[a] < labels[b]
+	})
+	if len(labels) > GraphLabelsMax {
+		labels = labels[:GraphLabelsMax]
+	}
+	c.Printf("    Labels: <white>%s</>\n", strings.Join(labels, ", "))
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return fmt.Errorf("getting all issues: %w", err)
+	}
+
+	flows, err := CalcIssueFlows(theCache, d.StatusModel(), *issues)
+	if err != nil {
+		return err
+	}
+
+	days, err := CalcDailyOpenByLabel(theCache, flows, labels, from, to)
+	if err != nil {
+		return fmt.Errorf("calculating daily open issues by label: %w", err)
+	}
+
+	xAxis := make([]string, 0, len(days))
+	lineData := map[string][]opts.LineData{}
+	for _, day := range days {
+		xAxis = append(xAxis, day.Date.Format("2006-01-02"))
+		for _, l := range labels {
+			lineData[l] = append(lineData[l], opts.LineData{Value: day.Labels[l]})
+		}
+	}
+
+	title := "Azure Team JIRAs Open by label (daily)"
+	if d.Name != "" {
+		title = d.Name + " JIRAs Open by label (daily)"
+	}
+
+	graph := charts.NewLine()
+	graph.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: fmt.Sprintf("The %d most used labels", len(labels)),
+			Left:     "center", // nolint:misspell
+		}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name: "Date",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "# Issues",
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "1500px",
+			Height: "750px",
+		}),
+		charts.WithToolboxOpts(opts.Toolbox{Show: true}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:    true,
+			Trigger: "axis",
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Show: true,
+			Top:  "bottom",
+			Left: "center", // nolint:misspell
+		}),
+	)
+	graph.SetXAxis(xAxis)
+
+	for _, l := range labels {
+		graph.AddSeries(l, lineData[l]).SetSeriesOptions(charts.WithLineStyleOpts(opts.LineStyle{Width: 2}))
+	}
+
+	outFile := outPath + "/daily-open-by-label.html"
+	file, err := os.Create(outFile) //nolint:gosec // CLI tool, path is not user-controlled
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	if err = graph.Render(file); err != nil {
+		return fmt.Errorf("failed to render graph: %w", err)
+	}
+
+	c.Printf("    <green>✓</> Wrote %s\n", outFile)
+
+	return nil
+}
+
+// GraphOpenIssuesBy renders the currently open issues grouped by a field such as assignee, largest first, each bar
+// stacked by normalised status. issues with several values, such as components, are counted under each
+func GraphOpenIssuesBy(theCache *cache.Cache, d Dataset, outPath, name string, group func(cache.Issue) []string) error {
+	c.Printf("\n  📊 Issues open by %s (stacked bar)\n", name)
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return fmt.Errorf("getting all issues: %w", err)
+	}
+
+	model := d.StatusModel()
+	flows, err := CalcIssueFlows(theCache, model, *issues)
+	if err != nil {
+		return err
+	}
+
+	open := 0
+	totals := map[string]int{}
+	counts := map[string]map[string]int{} // status -> group -> open
+	for _, f := range flows {
+		if f.Closed != nil {
+			continue
+		}
+		open++
+
+		status := model.Normalise(f.Issue.Status)
+		if counts[status] == nil {
+			counts[status] = map[string]int{}
+		}
+		for _, g := range group(f.Issue) {
+			counts[status][g]++
+			totals[g]++
+		}
+	}
+
+	groups := make([]string, 0, len(totals))
+	for g := range totals {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		if totals[groups[a]] != totals[groups[b]] {
+			return totals[groups[a]] > totals[groups[b]]
+		}
+		return groups[a] < groups[b]
+	})
+	c.Printf("    <white>%d</> open issues across <white>%d</> %s values\n", open, len(groups), name)
+
+	title := "Azure Team JIRAs Open by " + strings.ReplaceAll(name, "-", " ")
+	if d.Name != "" {
+		title = d.Name + " JIRAs Open by " + strings.ReplaceAll(name, "-", " ")
+	}
+
+	graph := charts.NewBar()
+	graph.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: "By Status: " + strings.Join(model.Statuses, ", "),
+			Left:     "center", // nolint:misspell
+		}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      strings.ToUpper(name[:1]) + strings.ReplaceAll(name[1:], "-", " "),
+			AxisLabel: &opts.AxisLabel{Rotate: 30, Interval: "0"},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "# Issues",
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "1500px",
+			Height: "750px",
+		}),
+		charts.WithToolboxOpts(opts.Toolbox{Show: true}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:    true,
+			Trigger: "axis",
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Show: true,
+			Top:  "bottom",
+			Left: "center", // nolint:misspell
+		}),
+	)
+	graph.SetXAxis(groups)
+
+	statuses := model.Statuses
+	if !model.Has("Other") {
+		statuses = append([]string{"Other"}, statuses...)
+	}
+	for _, status := range statuses {
+		if counts[status] == nil {
+			continue
+		}
+		data := make([]opts.BarData, 0, len(groups))
+		for _, g := range groups {
+			data = append(data, opts.BarData{Value: counts[status][g]})
+		}
+		graph.AddSeries(status, data).SetSeriesOptions(charts.WithBarChartOpts(opts.BarChart{Stack: "status"}))
+	}
+
+	outFile := outPath + "/open-by-" + name + ".html"
+	file, err := os.Create(outFile) //nolint:gosec // CLI tool, path is not user-controlled
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	if err = graph.Render(file); err != nil {
+		return fmt.Errorf("failed to render graph: %w", err)
+	}
+
+	c.Printf("    <green>✓</> Wrote %s\n", outFile)
+
+	return nil
+}
+
+// GraphEpicBurnUps renders a burn-up of each epic, its issues stacked by status category day by day so the top is its
+// scope and the bottom what is done. epics without issues or closed before the range are skipped
+func GraphEpicBurnUps(theCache *cache.Cache, d Dataset, w *Weight, outPath string, from, to time.Time) error {
+	c.Printf("\n  📊 Epic burn-ups (stacked area)\n")
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return fmt.Errorf("getting all issues: %w", err)
+	}
+
+	categories, err := theCache.GetStatusCategories()
+	if err != nil {
+		return err
+	}
+
+	model := d.StatusModel()
+	for _, e := range FindEpics(*issues) {
+		if len(e.Children) == 0 || (e.Issue != nil && e.Issue.IsClosed() && e.Issue.Updated.Before(from)) {
+			continue
+		}
+
+		// start when the first issue was, rather than a long flat line up to it
+		first := e.Children[0].Created
+		for _, i := range e.Children {
+			if i.Created.Before(first) {
+				first = i.Created
+			}
+		}
+		start := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, from.Location())
+		if start.Before(from) {
+			start = from
+		}
+
+		days, err := CalcEpicBurnUp(theCache, categories, model, w, e, start, to)
+		if err != nil {
+			return fmt.Errorf("calculating burn-up of %s: %w", e.Key, err)
+		}
+
+		xAxis := make([]string, 0, len(days))
+		lineData := map[string][]opts.LineData{}
+		for _, day := range days {
+			xAxis = append(xAxis, day.Date.Format("2006-01-02"))
+			for _, category := range StatusCategories {
+				lineData[category] = append(lineData[category], opts.LineData{Value: day.Categories[category]})
+			}
+		}
+
+		title := e.Key
+		if e.Issue != nil {
+			title += " " + e.Issue.Summary
+		}
+
+		graph := charts.NewLine()
+		graph.SetGlobalOptions(
+			charts.WithTitleOpts(opts.Title{
+				Title:    title,
+				Subtitle: fmt.Sprintf("%d issues by status category", len(e.Children)),
+				Left:     "center", // nolint:misspell
+			}),
+			charts.WithXAxisOpts(opts.XAxis{
+				Name: "Date",
+			}),
+			charts.WithYAxisOpts(opts.YAxis{
+				Name: w.Unit(),
+			}),
+			charts.WithInitializationOpts(opts.Initialization{
+				Width:  "1500px",
+				Height: "750px",
+			}),
+			charts.WithColorsOpts(opts.Colors{ //nolint:misspell // library type name
+				"#4AC16D", // Done, Light Green
+				"#365C8D", // In Progress, Dark Blue
+				"#A0A0A0", // To Do, Grey
+			}),
+			charts.WithToolboxOpts(opts.Toolbox{Show: true}),
+			charts.WithTooltipOpts(opts.Tooltip{
+				Show:    true,
+				Trigger: "axis",
+			}),
+			charts.WithLegendOpts(opts.Legend{
+				Show: true,
+				Top:  "bottom",
+				Left: "center", // nolint:misspell
+			}),
+		)
+		graph.SetXAxis(xAxis)
+
+		for _, category := range StatusCategories {
+			graph.AddSeries(category, lineData[category]).SetSeriesOptions(
+				charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.8}),
+				charts.WithLineChartOpts(opts.LineChart{Stack: "categories"}),
+				charts.WithLineStyleOpts(opts.LineStyle{Width: 1, Opacity: 0.9}),
+			)
+		}
+
+		outFile := outPath + "/epic-" + e.Key + "-burnup.html"
+		file, err := os.Create(outFile) //nolint:gosec // CLI tool, path is not user-controlled
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+
+		err = graph.Render(file)
+		file.Close() //nolint:errcheck,gosec
+		if err != nil {
+			return fmt.Errorf("failed to render graph: %w", err)
+		}
+
+		c.Printf("    <green>✓</> Wrote %s\n", outFile)
+	}
+
+	return nil
+}
+
+// GraphBlockers renders the open issues blocking each other as a dependency graph, arrows from blocker to blocked and
+// each issue sized by how many it blocks, so the chains and the issues holding up the most stand out
+func GraphBlockers(theCache *cache.Cache, d Dataset, outPath string) error {
+	c.Printf("\n  📊 Blockers (graph)\n")
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return fmt.Errorf("getting all issues: %w", err)
+	}
+
+	links, err := theCache.GetLinks()
+	if err != nil {
+		return err
+	}
+
+	categories, err := theCache.GetStatusCategories()
+	if err != nil {
+		return err
+	}
+
+	model := d.StatusModel()
+	edges := CalcBlockEdges(categories, model, *issues, links)
+	c.Printf("    <white>%d</> open blocks links\n", len(edges))
+
+	statuses := map[string]string{}
+	for _, l := range links {
+		statuses[l.Instance+"/"+l.LinkedKey] = l.LinkedStatus
+	}
+	for _, i := range *issues {
+		statuses[i.Instance+"/"+i.Key] = i.Status
+	}
+
+	// node names have to be unique, so only qualify keys with their instance when there are several
+	instances := map[string]bool{}
+	for _, e := range edges {
+		instances[e.Instance] = true
+	}
+	name := func(instance, key string) string {
+		if len(instances) > 1 {
+			return instance + "/" + key
+		}
+		return key
+	}
+
+	blocks := map[string]int{}
+	var keys []string
+	graphLinks := make([]opts.GraphLink, 0, len(edges))
+	for _, e := range edges {
+		for _, k := range []string{e.Instance + "/" + e.Blocker, e.Instance + "/" + e.Key} {
+			if _, ok := blocks[k]; !ok {
+				blocks[k] = 0
+				keys = append(keys, k)
+			}
+		}
+		blocks[e.Instance+"/"+e.Blocker]++
+		graphLinks = append(graphLinks, opts.GraphLink{Source: name(e.Instance, e.Blocker), Target: name(e.Instance, e.Key)})
+	}
+
+	category := map[string]int{}
+	graphCategories := make([]*opts.GraphCategory, 0, len(StatusCategories))
+	for n, sc := range StatusCategories {
+		category[sc] = n
+		graphCategories = append(graphCategories, &opts.GraphCategory{Name: sc})
+	}
+
+	nodes := make([]opts.GraphNode, 0, len(keys))
+	for _, k := range keys {
+		instance, key, _ := strings.Cut(k, "/")
+		nodes = append(nodes, opts.GraphNode{
+			Name:       name(instance, key),
+			Value:      float32(blocks[k]),
+			Category:   category[StatusCategory(categories, model, instance, statuses[k])],
+			SymbolSize: 15 + 5*min(blocks[k], 10),
+		})
+	}
+
+	title := "Azure Team JIRA Blockers"
+	if d.Name != "" {
+		title = d.Name + " JIRA Blockers"
+	}
+
+	graph := charts.NewGraph()
+	graph.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: "Arrows point from the blocker to the issue it blocks",
+			Left:     "center", // nolint:misspell
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "1500px",
+			Height: "750px",
+		}),
+		charts.WithColorsOpts(opts.Colors{ //nolint:misspell // library type name
+			"#4AC16D", // Done, Light Green
+			"#365C8D", // In Progress, Dark Blue
+			"#A0A0A0", // To Do, Grey
+		}),
+		charts.WithToolboxOpts(opts.Toolbox{Show: true}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),
+		charts.WithLegendOpts(opts.Legend{
+			Show: true,
+			Top:  "bottom",
+			Left: "center", // nolint:misspell
+		}),
+	)
+	graph.AddSeries("blocks", nodes, graphLinks,
+		charts.WithGraphChartOpts(opts.GraphChart{
+			Layout:             "force",
+			Force:              &opts.GraphForce{Repulsion: 200, EdgeLength: 80},
+			Roam:               true,
+			Draggable:          true,
+			FocusNodeAdjacency: true,
+			EdgeSymbol:         []string{"none", "arrow"},
+			EdgeSymbolSize:     10,
+			Categories:         graphCategories,
+		}),
+		charts.WithLabelOpts(opts.Label{Show: true, Position: "right"}),
+	)
+
+	outFile := outPath + "/blockers.html"
+	file, err := os.Create(outFile) //nolint:gosec // CLI tool, path is not user-controlled
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	if err = graph.Render(file); err != nil {
+		return fmt.Errorf("failed to render graph: %w", err)
+	}
+
+	c.Printf("    <green>✓</> Wrote %s\n", outFile)
+
+	return nil
+}
+
+// GraphSprintVelocity renders each board's sprints in the range, what each committed to, added, removed, completed and
+// carried over side by side with the rolling average velocity over them
+func GraphSprintVelocity(theCache *cache.Cache, d Dataset, w *Weight, outPath string, from, to time.Time) error {
+	c.Printf("\n  📊 Sprint velocity (bar)\n")
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return fmt.Errorf("getting all issues: %w", err)
+	}
+
+	categories, err := theCache.GetStatusCategories()
+	if err != nil {
+		return err
+	}
+
+	velocities, err := CalcSprintVelocityInRange(theCache, categories, d.StatusModel(), w, *issues, from, to, time.Now())
+	if err != nil {
+		return err
+	}
+	c.Printf("    <white>%d</> sprints\n", len(velocities))
+
+	// file names have to be unique, so only qualify boards with their instance when there are several
+	instances := map[string]bool{}
+	for _, v := range velocities {
+		instances[v.Instance] = true
+	}
+
+	var boards []string
+	byBoard := map[string][]SprintVelocity{}
+	for _, v := range velocities {
+		board := strconv.Itoa(v.BoardID)
+		if len(instances) > 1 {
+			board = v.Instance + "-" + board
+		}
+		if byBoard[board] == nil {
+			boards = append(boards, board)
+		}
+		byBoard[board] = append(byBoard[board], v)
+	}
+
+	for _, board := range boards {
+		sprints := byBoard[board]
+
+		series := []struct {
+			name  string
+			count func(SprintVelocity) float64
+		}{
+			{"Committed", func(v SprintVelocity) float64 { return v.Committed }},
+			{"Added", func(v SprintVelocity) float64 { return v.Added }},
+			{"Removed", func(v SprintVelocity) float64 { return v.Removed }},
+			{"Completed", func(v SprintVelocity) float64 { return v.Completed }},
+			{"Carried Over", func(v SprintVelocity) float64 { return v.CarriedOver }},
+		}
+
+		xAxis := make([]string, 0, len(sprints))
+		barData := make([][]opts.BarData, len(series))
+		lineData := make([]opts.LineData, 0, len(sprints))
+		for _, v := range sprints {
+			xAxis = append(xAxis, v.Name)
+			for n, s := range series {
+				barData[n] = append(barData[n], opts.BarData{Value: s.count(v)})
+			}
+			lineData = append(lineData, opts.LineData{Value: fmt.Sprintf("%.1f", v.AverageVelocity)})
+		}
+
+		title := "Azure Team JIRA Sprint Velocity"
+		if d.Name != "" {
+			title = d.Name + " JIRA Sprint Velocity"
+		}
+
+		graph := charts.NewBar()
+		graph.SetGlobalOptions(
+			charts.WithTitleOpts(opts.Title{
+				Title:    title,
+				Subtitle: fmt.Sprintf("Board %s, average velocity over the last %d completed sprints", board, VelocitySprints),
+				Left:     "center", // nolint:misspell
+			}),
+			charts.WithXAxisOpts(opts.XAxis{
+				Name:      "Sprint",
+				AxisLabel: &opts.AxisLabel{Rotate: 30, Interval: "0"},
+			}),
+			charts.WithYAxisOpts(opts.YAxis{
+				Name: w.Unit(),
+			}),
+			charts.WithInitializationOpts(opts.Initialization{
+				Width:  "1500px",
+				Height: "750px",
+			}),
+			charts.WithColorsOpts(opts.Colors{ //nolint:misspell // library type name
+				"#365C8D", // Committed, Dark Blue
+				"#FDE725", // Added, Yellow
+				"#D62728", // Removed, Red
+				"#4AC16D", // Completed, Light Green
+				"#FF7F0E", // Carried Over, Orange
+				"#000000", // Average Velocity, Black
+			}),
+			charts.WithToolboxOpts(opts.Toolbox{Show: true}),
+			charts.WithTooltipOpts(opts.Tooltip{
+				Show:    true,
+				Trigger: "axis",
+			}),
+			charts.WithLegendOpts(opts.Legend{
+				Show: true,
+				Top:  "bottom",
+				Left: "center", // nolint:misspell
+			}),
+		)
+		graph.SetXAxis(xAxis)
+		for n, s := range series {
+			graph.AddSeries(s.name, barData[n])
+		}
+
+		average := charts.NewLine()
+		average.SetXAxis(xAxis).AddSeries("Average Velocity", lineData).SetSeriesOptions(
+			charts.WithLineStyleOpts(opts.LineStyle{Width: 2}),
+		)
+		graph.Overlap(average)
+
+		outFile := outPath + "/sprint-velocity-" + board + ".html"
+		file, err := os.Create(outFile) //nolint:gosec // CLI tool, path is not user-controlled
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+
+		err = graph.Render(file)
+		file.Close() //nolint:errcheck,gosec
+		if err != nil {
+			return fmt.Errorf("failed to render graph: %w", err)
+		}
+
+		c.Printf("    <green>✓</> Wrote %s\n", outFile)
+	}
+
+	return nil
+}
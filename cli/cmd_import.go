@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/spf13/cobra"
+)
+
+// ImportDataset is the dataset imported issues are added to when no --dataset is given, kept apart from fetched
+// datasets so a fetch never tombstones them for not being returned by its jql
+const ImportDataset = "import"
+
+// CmdImport loads jira csv or json exports into the cache as if they had been fetched
+func CmdImport(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	instance := f.Instance
+	if instance == "" {
+		instance = cache.DefaultInstance
+	}
+
+	dataset := f.Dataset
+	if dataset == "" {
+		dataset = ImportDataset
+	}
+
+	// csv exports have no urls so browse links are built from --url or the configured instance
+	baseURL := f.Url
+	if instances, err := GetInstances(f); err == nil {
+		if i, err := GetInstance(instances, instance); err == nil {
+			baseURL = i.URL
+		}
+	}
+
+	theCache, err := cache.Open(f.CachePath)
+	if err != nil {
+		return fmt.Errorf("opening cache %s: %w", f.CachePath, err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	for _, path := range args {
+		issues, err := readExport(path, baseURL)
+		if err != nil {
+			return err
+		}
+
+		c.Printf("Importing <cyan>%d</> issues from <white>%s</> into <white>%s</>/<white>%s</>...\n", len(issues), path, instance, dataset)
+
+		events := 0
+		for _, i := range issues {
+			if i.Self == "" && baseURL != "" {
+				i.Self = strings.TrimRight(baseURL, "/") + "/rest/api/2/issue/" + i.ID
+			}
+
+			if err := theCache.UpsertIssueFromJIRA(instance, i); err != nil {
+				return fmt.Errorf("cache issue upsert failed: %w", err)
+			}
+			if err := theCache.LinkIssueToDataset(dataset, instance, i.Key); err != nil {
+				return err
+			}
+
+			count, err := theCache.UpsertEventsFromIssue(instance, i)
+			if err != nil {
+				return fmt.Errorf("cache issue events upsert failed: %w", err)
+			}
+			events += *count
+
+			if _, err := theCache.UpsertAliasesFromIssue(instance, i); err != nil {
+				return fmt.Errorf("cache issue aliases upsert failed: %w", err)
+			}
+		}
+
+		c.Printf("  <green>%d</> issues with <cyan>%d</> new events\n", len(issues), events)
+	}
+
+	return nil
+}
+
+// readExport parses a csv or json export by its extension
+func readExport(path, baseURL string) ([]*models.IssueScheme, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening export %s: %w", path, err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	var issues []*models.IssueScheme
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		issues, err = j.ParseExportCSV(file, baseURL)
+	case ".json":
+		issues, err = j.ParseExportJSON(file)
+	default:
+		return nil, fmt.Errorf("unknown export type %s, expected .csv or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/spf13/cobra"
+)
+
+// IssueTimeline is everything the cache knows about one issue
+type IssueTimeline struct {
+	QueryIssue
+	Updated   string             `json:"updated"`
+	Missing   string             `json:"missing,omitempty"`
+	Aliases   []string           `json:"aliases,omitempty"`
+	Links     []string           `json:"links,omitempty"` // as jira describes them, such as "is blocked by AB-1"
+	Sprints   []string           `json:"sprints,omitempty"`
+	Numbers   map[string]float64 `json:"numbers,omitempty"` // number fields such as story_points
+	DaysOpen  float64            `json:"days_open"`
+	LeadDays  *float64           `json:"lead_days,omitempty"`
+	CycleDays *float64           `json:"cycle_days,omitempty"`
+	Statuses  map[string]float64 `json:"status_days"`
+	Events    []TimelineEvent    `json:"events"`
+}
+
+type TimelineEvent struct {
+	Date   time.Time `json:"date"`
+	Author string    `json:"author"`
+	Field  string    `json:"field"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+}
+
+// CmdIssue prints the timeline of a single issue, looked up by its current or any previous key
+func CmdIssue(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	if f.Output != "text" && f.Output != "json" {
+		return fmt.Errorf("invalid output %q, expected text or json", f.Output)
+	}
+	if f.Output == "json" {
+		c.SetOutput(os.Stderr)
+	}
+
+	instance := f.Instance
+	if instance == "" {
+		instance = cache.DefaultInstance
+	}
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+	model := d.StatusModel()
+
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	i, err := theCache.GetIssue(instance, strings.ToUpper(args[0]))
+	if err != nil {
+		return err
+	}
+	if i == nil {
+		return fmt.Errorf("issue %s not found in %s/%s", args[0], instance, f.CachePath)
+	}
+
+	t, err := CalcIssueTimeline(theCache, model, *i, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if f.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t)
+	}
+
+	printIssueTimeline(t)
+	return nil
+}
+
+// CalcIssueTimeline gathers an issue's fields, events and derived metrics
+func CalcIssueTimeline(theCache *cache.Cache, model StatusModel, i cache.Issue, now time.Time) (*IssueTimeline, error) {
+	events, err := theCache.GetIssueEvents(i.Instance, i.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := theCache.GetAliasesForIssue(i.Instance, i.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := theCache.GetIssueLinks(i.Instance, i.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	sprints, err := theCache.GetSprintsForIssue(i.Instance, i.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers, err := theCache.GetIssueNumbers(i.Instance, i.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var statusEvents []cache.Event
+	for _, e := range events {
+		if e.Field == "status" {
+			statusEvents = append(statusEvents, e)
+		}
+	}
+	flow := CalcIssueFlow(model, i, statusEvents)
+
+	t := IssueTimeline{
+		QueryIssue: QueryIssue{
+			Instance:   i.Instance,
+			Key:        i.Key,
+			URL:        i.URL,
+			Type:       i.Type,
+			Status:     i.Status,
+			Group:      model.Normalise(i.Status),
+			Resolution: i.Resolution,
+			Summary:    i.Summary,
+			Labels:     i.Labels,
+			Creator:    i.Creator,
+			Assignee:   i.Assignee,
+			Reporter:   i.Reporter,
+			Priority:   i.Priority,
+			Components: i.Components,
+			Versions:   i.FixVersions,
+			Affects:    i.AffectedVersions,
+			Parent:     i.Parent,
+			Created:    i.Created.Format(time.RFC3339),
+		},
+		Updated:  i.Updated.Format(time.RFC3339),
+		Statuses: CalcTimeInStatus(flow, statusEvents, now),
+		Events:   []TimelineEvent{},
+	}
+
+	end := now
+	if flow.Started != nil {
+		t.Started = flow.Started.Format(time.RFC3339)
+	}
+	if flow.Closed != nil {
+		end = *flow.Closed
+		t.Closed = flow.Closed.Format(time.RFC3339)
+		lead := flow.LeadDays()
+		t.LeadDays = &lead
+		if flow.Started != nil {
+			cycle := flow.CycleDays()
+			t.CycleDays = &cycle
+		}
+	}
+	t.DaysOpen = end.Sub(i.Created).Hours() / 24
+
+	if i.Missing.Valid {
+		t.Missing = i.Missing.Time.Format(time.RFC3339)
+	}
+	for _, a := range aliases {
+		t.Aliases = append(t.Aliases, a.Alias)
+	}
+	for _, l := range links {
+		t.Links = append(t.Links, l.Relation+" "+l.LinkedKey)
+	}
+	for _, s := range sprints {
+		t.Sprints = append(t.Sprints, s.Name)
+	}
+	if len(numbers) > 0 {
+		t.Numbers = numbers
+	}
+	for _, e := range events {
+		t.Events = append(t.Events, TimelineEvent{Date: e.Date, Author: e.Author, Field: e.Field, From: e.From, To: e.To})
+	}
+
+	return &t, nil
+}
+
+func printIssueTimeline(t *IssueTimeline) {
+	c.Printf("<white>%s</> <cyan>%s</>\n", t.Key, t.Summary)
+	c.Printf("  instance:   %s\n", t.Instance)
+	c.Printf("  url:        %s\n", t.URL)
+	c.Printf("  type:       %s\n", t.Type)
+	c.Printf("  status:     <cyan>%s</> (%s)\n", t.Status, t.Group)
+	if t.Resolution != "" {
+		c.Printf("  resolution: %s\n", t.Resolution)
+	}
+	c.Printf("  labels:     %s\n", strings.Join(t.Labels, ", "))
+	c.Printf("  creator:    %s\n", t.Creator)
+	c.Printf("  reporter:   %s\n", t.Reporter)
+	c.Printf("  assignee:   %s\n", AssigneeName(t.Assignee))
+	c.Printf("  priority:   %s\n", PriorityName(t.Priority))
+	if len(t.Components) > 0 {
+		c.Printf("  components: %s\n", strings.Join(t.Components, ", "))
+	}
+	if len(t.Versions) > 0 {
+		c.Printf("  fix:        %s\n", strings.Join(t.Versions, ", "))
+	}
+	if len(t.Affects) > 0 {
+		c.Printf("  affects:    %s\n", strings.Join(t.Affects, ", "))
+	}
+	if t.Parent != "" {
+		c.Printf("  parent:     %s\n", t.Parent)
+	}
+	c.Printf("  created:    %s\n", t.Created)
+	c.Printf("  updated:    %s\n", t.Updated)
+	if len(t.Aliases) > 0 {
+		c.Printf("  aliases:    %s\n", strings.Join(t.Aliases, ", "))
+	}
+	if len(t.Links) > 0 {
+		c.Printf("  links:      %s\n", strings.Join(t.Links, ", "))
+	}
+	if len(t.Sprints) > 0 {
+		c.Printf("  sprints:    %s\n", strings.Join(t.Sprints, ", "))
+	}
+	names := make([]string, 0, len(t.Numbers))
+	for n := range t.Numbers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		c.Printf("  %-11s <white>%g</>\n", n+":", t.Numbers[n])
+	}
+	if t.Missing != "" {
+		c.Printf("  <yellow>missing:</>    %s\n", t.Missing)
+	}
+
+	c.Printf("\n<white>Metrics</>\n")
+	if t.Started != "" {
+		c.Printf("  started:    %s\n", t.Started)
+	}
+	if t.Closed != "" {
+		c.Printf("  closed:     %s\n", t.Closed)
+	}
+	c.Printf("  days open:  <cyan>%.1f</>\n", t.DaysOpen)
+	if t.LeadDays != nil {
+		c.Printf("  lead time:  <cyan>%.1f</> days\n", *t.LeadDays)
+	}
+	if t.CycleDays != nil {
+		c.Printf("  cycle time: <cyan>%.1f</> days\n", *t.CycleDays)
+	}
+
+	c.Printf("\n<white>Time in status</>\n")
+	statuses := make([]string, 0, len(t.Statuses))
+	for s := range t.Statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(a, b int) bool {
+		return t.Statuses[statuses[a]] > t.Statuses[statuses[b]]
+	})
+	for _, s := range statuses {
+		c.Printf("  %-24s <cyan>%6.1f</> days\n", s, t.Statuses[s])
+	}
+
+	c.Printf("\n<white>Events</> (%d)\n", len(t.Events))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range t.Events {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s -> %s\n", e.Date.Format("2006-01-02 15:04"), e.Author, e.Field, e.From, e.To) //nolint:errcheck
+	}
+	w.Flush() //nolint:errcheck
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	c "github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+// QueryIssue is an issue matched by the query command in its json output
+type QueryIssue struct {
+	Instance   string   `json:"instance"`
+	Key        string   `json:"key"`
+	URL        string   `json:"url"`
+	Type       string   `json:"type"`
+	Status     string   `json:"status"`
+	Group      string   `json:"status_group"`
+	Resolution string   `json:"resolution,omitempty"`
+	Summary    string   `json:"summary"`
+	Labels     []string `json:"labels"`
+	Creator    string   `json:"creator"`
+	Assignee   string   `json:"assignee"`
+	Reporter   string   `json:"reporter"`
+	Priority   string   `json:"priority"`
+	Components []string `json:"components"`
+	Versions   []string `json:"fix_versions"`
+	Affects    []string `json:"affected_versions"`
+	Parent     string   `json:"parent,omitempty"`
+	Created    string   `json:"created"`
+	Started    string   `json:"started,omitempty"`
+	Closed     string   `json:"closed,omitempty"`
+}
+
+func CmdQuery(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	if f.Output != "text" && f.Output != "json" && f.Output != "keys" {
+		return fmt.Errorf("invalid output %q, expected text, json or keys", f.Output)
+	}
+
+	q, err := ParseQuery(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	if f.Output != "text" {
+		// keep stdout for the results
+		c.SetOutput(os.Stderr)
+	}
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+	model := d.StatusModel()
+
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return err
+	}
+
+	flows, err := CalcIssueFlows(theCache, model, *issues)
+	if err != nil {
+		return err
+	}
+
+	var matched []IssueFlow
+	for _, fl := range flows {
+		if q.Match(model, fl) {
+			matched = append(matched, fl)
+		}
+	}
+	sort.Slice(matched, func(a, b int) bool {
+		return matched[a].Issue.Created.Before(matched[b].Issue.Created)
+	})
+
+	switch f.Output {
+	case "keys":
+		for _, fl := range matched {
+			fmt.Println(fl.Issue.Key)
+		}
+
+	case "json":
+		results := make([]QueryIssue, 0, len(matched))
+		for _, fl := range matched {
+			i := fl.Issue
+			r := QueryIssue{
+				Instance:   i.Instance,
+				Key:        i.Key,
+				URL:        i.URL,
+				Type:       i.Type,
+				Status:     i.Status,
+				Group:      model.Normalise(i.Status),
+				Resolution: i.Resolution,
+				Summary:    i.Summary,
+				Labels:     i.Labels,
+				Creator:    i.Creator,
+				Assignee:   i.Assignee,
+				Reporter:   i.Reporter,
+				Priority:   i.Priority,
+				Components: i.Components,
+				Versions:   i.FixVersions,
+				Affects:    i.AffectedVersions,
+				Parent:     i.Parent,
+				Created:    i.Created.Format(APIDateFormat),
+			}
+			if fl.Started != nil {
+				r.Started = fl.Started.Format(APIDateFormat)
+			}
+			if fl.Closed != nil {
+				r.Closed = fl.Closed.Format(APIDateFormat)
+			}
+			results = append(results, r)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+
+	case "text":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tTYPE\tSTATUS\tCREATED\tCLOSED\tSUMMARY") //nolint:errcheck
+		for _, fl := range matched {
+			i := fl.Issue
+			closed := ""
+			if fl.Closed != nil {
+				closed = fl.Closed.Format(APIDateFormat)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", i.Key, i.Type, i.Status, i.Created.Format(APIDateFormat), closed, i.Summary) //nolint:errcheck
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		c.Printf("<cyan>%d</> of %d issues matched\n", len(matched), len(flows))
+	}
+
+	return nil
+}
@@ -1,11 +1,176 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	c "github.com/gookit/color"
 	"github.com/spf13/cobra"
 )
 
-// todo, something we need to do or will fancy graphs be enough?
-
 func CmdReport(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	// default to last month till now
+	from, to, err := ParseMonthRange(args, time.Now().AddDate(0, -1, 0))
+	if err != nil {
+		return err
+	}
+
+	if f.Output != "text" && f.Output != "json" {
+		return fmt.Errorf("invalid output %q, expected text or json", f.Output)
+	}
+
+	// keep progress output off stdout when it is the json document
+	if f.Output == "json" {
+		c.SetOutput(os.Stderr)
+	}
+	cache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer cache.DB.Close() //nolint:errcheck
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+
+	weight, err := NewWeight(cache, f.Weight)
+	if err != nil {
+		return err
+	}
+
+	r, err := BuildReport(cache, d.StatusModel(), weight, from, to)
+	if err != nil {
+		return fmt.Errorf("building report: %w", err)
+	}
+
+	if f.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("encoding report: %w", err)
+		}
+		return nil
+	}
+
+	c.Printf("Report for <white>%s</> to <white>%s</>\n", r.From, r.To)
+	if d.Name != "" {
+		c.Printf("  Dataset <white>%s</>\n", d.Name)
+	}
+	if len(f.Labels) > 0 {
+		c.Printf("  Labels <white>%s</>\n", strings.Join(f.Labels, ", "))
+	}
+	if len(f.Components) > 0 {
+		c.Printf("  Components <white>%s</>\n", strings.Join(f.Components, ", "))
+	}
+	if len(f.FixVersions) > 0 {
+		c.Printf("  Fix versions <white>%s</>\n", strings.Join(f.FixVersions, ", "))
+	}
+	if !weight.Counts() {
+		c.Printf("  Weighted by <white>%s</>\n", weight.Unit())
+	}
+	c.Printf("  Created <cyan>%g</>, closed <green>%g</>, open <yellow>%d</>\n", r.Summary.Created, r.Summary.Closed, r.Summary.Open)
+	c.Printf("  Median lead time <white>%.1f</> days, cycle time <white>%.1f</> days\n", r.Summary.MedianLeadDays, r.Summary.MedianCycleDays)
+
+	c.Printf("  Open by status:\n")
+	statuses := make([]string, 0, len(r.Summary.OpenByStatus))
+	for s := range r.Summary.OpenByStatus {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+	for _, s := range statuses {
+		c.Printf("    <darkGray>%4d</> %s\n", r.Summary.OpenByStatus[s], colorizeStatus(s))
+	}
+
+	c.Printf("  Open by assignee:\n")
+	printBreakdown(r.Summary.OpenByAssignee)
+
+	c.Printf("  Open by priority:\n")
+	printBreakdown(r.Summary.OpenByPriority)
+
+	c.Printf("  Open by label:\n")
+	printBreakdown(r.Summary.OpenByLabel)
+
+	c.Printf("  Open by component:\n")
+	printBreakdown(r.Summary.OpenByComponent)
+
+	if len(r.Versions) > 0 {
+		c.Printf("  Fix versions:\n")
+		for _, v := range r.Versions {
+			release := "unscheduled"
+			if v.ReleaseDate != "" {
+				release = v.ReleaseDate
+			}
+			if v.Released {
+				release += " released"
+			}
+			c.Printf("    <white>%-20s</> <darkGray>%-22s</> open <yellow>%3d</> closed <green>%3d</>\n", v.Name, release, v.Open, v.Closed)
+		}
+	}
+
+	c.Printf("  Epics in progress:\n")
+	for _, e := range r.Epics {
+		if e.Children > 0 && e.Done < e.Children {
+			printEpicProgress(e)
+		}
+	}
+
+	if len(r.Blocked) > 0 {
+		c.Printf("  Blocked:\n")
+		for _, b := range r.Blocked {
+			chain := ""
+			if b.Depth > 1 {
+				chain = fmt.Sprintf(" <red>(chain of %d)</>", b.Depth)
+			}
+			c.Printf("    <yellow>%-12s</> <darkGray>%4.0f days since %s</> by <red>%s</>%s %s\n", b.Key, b.DaysBlocked, b.Since, strings.Join(b.BlockedBy, ", "), chain, b.Summary)
+		}
+	}
+
+	if len(r.Sprints) > 0 {
+		c.Printf("  Sprints:\n")
+		for _, s := range r.Sprints {
+			c.Printf("    <white>%-24s</> <darkGray>%-8s %s to %s</> committed <cyan>%3g</> added <yellow>%3g</> removed <red>%3g</> completed <green>%3g</> carried over <lightRed>%3g</> average <white>%5.1f</>\n", s.Name, s.State, s.Start, s.End, s.Committed, s.Added, s.Removed, s.Completed, s.CarriedOver, s.AverageVelocity)
+		}
+	}
+
+	if len(r.Missing) > 0 {
+		c.Printf("  No longer returned by the jql (excluded):\n")
+		for _, i := range r.Missing {
+			c.Printf("    <darkGray>%s</> <yellow>%s</> %s <darkGray>(%s)</>\n", i.Missing, i.Key, i.Summary, i.Status)
+		}
+	}
+
+	c.Printf("  Weekly throughput:\n")
+	for _, w := range r.Throughput {
+		if w.Created == 0 && w.Closed == 0 {
+			continue
+		}
+		c.Printf("    <darkGray>%s</> created <cyan>%3g</> closed <green>%3g</>\n", w.Week, w.Created, w.Closed)
+	}
+
 	return nil
 }
+
+// printBreakdown prints counts largest first, ties by name
+func printBreakdown(counts map[string]int) {
+	names := make([]string, 0, len(counts))
+	for n := range counts {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if counts[names[a]] != counts[names[b]] {
+			return counts[names[a]] > counts[names[b]]
+		}
+		return names[a] < names[b]
+	})
+
+	for _, n := range names {
+		c.Printf("    <darkGray>%4d</> %s\n", counts[n], n)
+	}
+}
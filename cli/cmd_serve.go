@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/clog"
+	"github.com/spf13/cobra"
+)
+
+func CmdServe(_ *cobra.Command, _ []string) error {
+	f := GetFlags()
+
+	cache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer cache.DB.Close() //nolint:errcheck
+
+	mux := http.NewServeMux()
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+
+	RegisterAPI(mux, cache, f)
+	mux.Handle("/metrics", MetricsHandler(cache, d.StatusModel()))
+	if f.WebhookSecret != "" {
+		instances, err := GetInstances(f)
+		if err != nil {
+			return err
+		}
+		datasets, err := GetDatasets(f)
+		if err != nil {
+			return err
+		}
+
+		mux.Handle("/webhooks/jira", WebhookHandler(cache, f.WebhookSecret, instances, datasets))
+		c.Printf("  accepting jira webhooks on <cyan>/webhooks/jira</>\n")
+	}
+	mux.Handle("/", http.FileServer(http.Dir(GraphsPath)))
+
+	c.Printf("Serving API, metrics and graphs on <cyan>%s</>...\n", f.Addr)
+	server := &http.Server{
+		Addr:              f.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server.ListenAndServe()
+}
+
+// RegisterAPI adds the read only JSON API endpoints to mux, dataset and instance query parameters override --dataset and
+// --instance. each endpoint computes only the parts of the report it serves
+func RegisterAPI(mux *http.ServeMux, theCache *cache.Cache, f FlagData) {
+	endpoint := func(path string, parts reportPart, pick func(*Report) any) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			var args []string
+			if from := r.URL.Query().Get("from"); from != "" {
+				args = append(args, from)
+				if to := r.URL.Query().Get("to"); to != "" {
+					args = append(args, to)
+				}
+			}
+
+			from, to, err := ParseMonthRange(args, time.Now().AddDate(-1, 0, 0))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			df := f
+			if dataset := r.URL.Query().Get("dataset"); dataset != "" {
+				df.Dataset = dataset
+			}
+			d, err := GetDataset(df)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// a copy so the dataset, instance, labels, components and versions only apply to this request
+			dc := *theCache
+			dc.Dataset = df.Dataset
+			if instance := r.URL.Query().Get("instance"); instance != "" {
+				dc.Instance = instance
+			}
+			if labels := r.URL.Query()["label"]; len(labels) > 0 {
+				dc.Labels = labels
+			}
+			if components := r.URL.Query()["component"]; len(components) > 0 {
+				dc.Components = components
+			}
+			if versions := r.URL.Query()["fix_version"]; len(versions) > 0 {
+				dc.FixVersions = versions
+			}
+
+			weight := f.Weight
+			if wq := r.URL.Query().Get("weight"); wq != "" {
+				weight = wq
+			}
+			wt, err := NewWeight(&dc, weight)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			report, err := buildReport(&dc, d.StatusModel(), wt, from, to, parts)
+			if err != nil {
+				clog.Log.Errorf("building report for %s: %v", path, err)
+				http.Error(w, "failed to build report", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(pick(report)); err != nil {
+				clog.Log.Errorf("encoding response for %s: %v", path, err)
+			}
+		})
+	}
+
+	endpoint("/api/v1/report", reportAll, func(r *Report) any { return r })
+	endpoint("/api/v1/daily", reportDaily, func(r *Report) any { return r.Daily })
+	endpoint("/api/v1/throughput", reportThroughput, func(r *Report) any { return r.Throughput })
+	endpoint("/api/v1/cycle-times", reportCycleTimes, func(r *Report) any { return r.CycleTimes })
+	endpoint("/api/v1/open", reportOpen, func(r *Report) any { return r.Open })
+	endpoint("/api/v1/missing", reportMissing, func(r *Report) any { return r.Missing })
+	endpoint("/api/v1/versions", reportVersions, func(r *Report) any { return r.Versions })
+	endpoint("/api/v1/epics", reportEpics, func(r *Report) any { return r.Epics })
+	endpoint("/api/v1/blocked", reportBlocked, func(r *Report) any { return r.Blocked })
+	endpoint("/api/v1/sprints", reportSprints, func(r *Report) any { return r.Sprints })
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+func TestAPIEndpointsMatchReport(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	for _, issue := range []*jiratest.Issue{
+		jiratest.NewIssue("AB-1", "To Do", created).FixVersion("v1").
+			Transition(created.Add(24*time.Hour), "To Do", "In Progress").
+			Transition(created.Add(72*time.Hour), "In Progress", "Closed"),
+		jiratest.NewIssue("AB-2", "To Do", created).FixVersion("v1").Parent("AB-3"),
+		jiratest.NewIssue("AB-3", "To Do", created).Type("Epic"),
+	} {
+		if err := theCache.UpsertIssueFromJIRA(cache.DefaultInstance, issue.IssueScheme); err != nil {
+			t.Fatalf("caching %s: %v", issue.Key, err)
+		}
+		if _, err := theCache.UpsertEventsFromIssue(cache.DefaultInstance, issue.IssueScheme); err != nil {
+			t.Fatalf("caching %s events: %v", issue.Key, err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	RegisterAPI(mux, theCache, FlagData{})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(path string, v any) {
+		t.Helper()
+
+		resp, err := http.Get(server.URL + path + "?from=2024-01&to=2024-02") //nolint:noctx
+		if err != nil {
+			t.Fatalf("requesting %s: %v", path, err)
+		}
+		defer resp.Body.Close() //nolint:errcheck
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected %s to be ok, got %s", path, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("decoding %s: %v", path, err)
+		}
+	}
+
+	var full map[string]json.RawMessage
+	get("/api/v1/report", &full)
+
+	for path, part := range map[string]string{
+		"/api/v1/daily":       "daily",
+		"/api/v1/throughput":  "throughput",
+		"/api/v1/cycle-times": "cycle_times",
+		"/api/v1/missing":     "missing",
+		"/api/v1/versions":    "versions",
+		"/api/v1/epics":       "epics",
+		"/api/v1/blocked":     "blocked",
+		"/api/v1/sprints":     "sprints",
+	} {
+		var got json.RawMessage
+		get(path, &got)
+		if string(got) != string(full[part]) {
+			t.Errorf("expected %s to be the report's %s\n%s\ngot\n%s", path, part, full[part], got)
+		}
+	}
+
+	// days open moves on between requests
+	var open []OpenIssue
+	get("/api/v1/open", &open)
+	if len(open) != 2 || (open[0].Key != "AB-2" && open[1].Key != "AB-2") {
+		t.Errorf("expected AB-2 and AB-3 to be open, got %+v", open)
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/spf13/cobra"
+)
+
+// CmdSprints prints every sprint with how many issues it started with, how many were added and removed mid-sprint and
+// how many got done, or the issues of the given sprints
+func CmdSprints(_ *cobra.Command, args []string) error {
+	f := GetFlags()
+
+	if f.Output != "text" && f.Output != "json" {
+		return fmt.Errorf("invalid output %q, expected text or json", f.Output)
+	}
+	if f.Output == "json" {
+		c.SetOutput(os.Stderr)
+	}
+
+	want := map[int]bool{}
+	for _, a := range args {
+		id, err := strconv.Atoi(a)
+		if err != nil {
+			return fmt.Errorf("invalid sprint id %q: %w", a, err)
+		}
+		want[id] = true
+	}
+
+	d, err := GetDataset(f)
+	if err != nil {
+		return err
+	}
+
+	theCache, err := OpenCache(f)
+	if err != nil {
+		return err
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	sprints, err := theCache.GetSprints()
+	if err != nil {
+		return err
+	}
+	if len(want) > 0 {
+		var wanted []cache.Sprint
+		for _, s := range sprints {
+			if want[s.ID] {
+				wanted = append(wanted, s)
+				delete(want, s.ID)
+			}
+		}
+		for id := range want {
+			return fmt.Errorf("sprint %d not found in %s", id, f.CachePath)
+		}
+		sprints = wanted
+	}
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return err
+	}
+
+	categories, err := theCache.GetStatusCategories()
+	if err != nil {
+		return err
+	}
+
+	scopes, err := CalcSprintScopes(theCache, categories, d.StatusModel(), sprints, *issues, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if f.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(scopes)
+	}
+
+	c.Printf("<white>%d</> sprints\n", len(scopes))
+	for _, s := range scopes {
+		dates := "not started"
+		if s.Start != "" {
+			dates = s.Start + " to " + s.End
+		}
+		c.Printf("  <darkGray>%6d</> <white>%-24s</> <darkGray>%-8s %-24s</> committed <cyan>%3d</> added <yellow>%3d</> removed <red>%3d</> done <green>%3d</> carried over <lightRed>%3d</>\n", s.ID, s.Name, s.State, dates, len(s.Committed), len(s.Added), len(s.Removed), len(s.Done), len(s.CarriedOver))
+
+		if len(args) > 0 {
+			c.Printf("      committed: %s\n", strings.Join(s.Committed, ", "))
+			c.Printf("      added:     <yellow>%s</>\n", strings.Join(s.Added, ", "))
+			c.Printf("      removed:   <red>%s</>\n", strings.Join(s.Removed, ", "))
+			c.Printf("      done:      <green>%s</>\n", strings.Join(s.Done, ", "))
+			c.Printf("      carried:   <lightRed>%s</>\n", strings.Join(s.CarriedOver, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMonthRange(t *testing.T) {
+	t.Parallel()
+
+	defaultFrom := time.Date(2023, 6, 17, 12, 0, 0, 0, time.UTC)
+	month := func(year int, m time.Month) time.Time {
+		return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name     string
+		args     []string
+		from, to time.Time // a zero to is now
+		err      bool
+	}{
+		{name: "defaults to the start of the default month till now", from: month(2023, 6)},
+		{name: "a single month is from then till now", args: []string{"2024-02"}, from: month(2024, 2)},
+		{name: "two months are a range", args: []string{"2024-02", "2024-05"}, from: month(2024, 2), to: month(2024, 5)},
+		{name: "invalid from", args: []string{"2024-13"}, err: true},
+		{name: "invalid to", args: []string{"2024-02", "may"}, err: true},
+	}
+
+	for _, tc := range cases {
+		before := time.Now()
+		from, to, err := ParseMonthRange(tc.args, defaultFrom)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		if !from.Equal(tc.from) {
+			t.Errorf("%s: expected from %s, got %s", tc.name, tc.from, from)
+		}
+		if tc.to.IsZero() && (to.Before(before) || to.After(time.Now())) {
+			t.Errorf("%s: expected to be now, got %s", tc.name, to)
+		}
+		if !tc.to.IsZero() && !to.Equal(tc.to) {
+			t.Errorf("%s: expected to %s, got %s", tc.name, tc.to, to)
+		}
+	}
+}
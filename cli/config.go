@@ -0,0 +1,391 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/spf13/viper"
+)
+
+// Instance is a named jira site, credentials are never in the config file only the name of the env var holding them:
+//
+//	instances:
+//	  - name: cloud
+//	    url: https://example.atlassian.net
+//	    user: me@example.com
+//	    token_env: CLOUD_JIRA_TOKEN
+//	  - name: onprem
+//	    url: https://jira.example.com
+//	    deployment: server   # cloud, server (or data center) or auto, the default, to detect it
+//	    token_env: ONPREM_JIRA_PAT   # with no user a personal access token is sent as a bearer token
+//
+// tokens can also be read from a file or a command with token_file and token_command, or an oauth 2.0 token authorised
+// with the auth command used instead:
+//
+//   - name: daemon
+//     oauth_token_file: /var/lib/gogo-jira-stats/oauth.json
+//     oauth_client_id: abc123
+//     oauth_client_secret_env: JIRA_OAUTH_CLIENT_SECRET
+//
+// the classic Epic Link and sprint custom fields are discovered when fetching, epic_link_field and sprint_field set
+// them for sites where that fails. numeric custom fields are fetched by mapping a name to them, story points are
+// discovered as story_points when not mapped:
+//
+//	number_fields:
+//	  - name: story_points
+//	    field: customfield_10016
+//	  - name: business_value
+//	    field: customfield_10050
+//
+// when no instances are configured --url, --user and --token are used as the default instance
+type Instance struct {
+	Name         string `mapstructure:"name"`
+	URL          string `mapstructure:"url"`
+	User         string `mapstructure:"user"`
+	UserEnv      string `mapstructure:"user_env"`
+	TokenEnv     string `mapstructure:"token_env"`
+	TokenFile    string `mapstructure:"token_file"`
+	TokenCommand string `mapstructure:"token_command"`
+	Deployment   string `mapstructure:"deployment"`
+
+	EpicLinkField string `mapstructure:"epic_link_field"`
+	SprintField   string `mapstructure:"sprint_field"`
+
+	NumberFields []NumberFieldMapping `mapstructure:"number_fields"`
+
+	OAuthTokenFile       string `mapstructure:"oauth_token_file"`
+	OAuthClientID        string `mapstructure:"oauth_client_id"`
+	OAuthClientSecretEnv string `mapstructure:"oauth_client_secret_env"`
+}
+
+type NumberFieldMapping struct {
+	Name  string `mapstructure:"name"`
+	Field string `mapstructure:"field"`
+}
+
+// Dataset is a named jql query on an instance with its own status model, configured in the config file as:
+//
+//	datasets:
+//	  - name: azure
+//	    instance: cloud   # optional when there is only one instance
+//	    jql: project = AZ
+//	    statuses: [Other, To Do, In Progress, In Review]   # stack order, defaults to DefaultStatusModel
+//	    mappings:
+//	      - from: In Development
+//	        to: In Progress
+//
+// when no datasets are configured --jql is used as the default dataset
+type Dataset struct {
+	Name     string          `mapstructure:"name"`
+	Instance string          `mapstructure:"instance"`
+	JQL      string          `mapstructure:"jql"`
+	Statuses []string        `mapstructure:"statuses"`
+	Mappings []StatusMapping `mapstructure:"mappings"`
+}
+
+// mappings are a list rather than a map as viper lower cases map keys
+type StatusMapping struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+func (d Dataset) StatusModel() StatusModel {
+	if len(d.Statuses) == 0 && len(d.Mappings) == 0 {
+		return DefaultStatusModel
+	}
+
+	m := StatusModel{
+		Statuses: d.Statuses,
+		Mappings: map[string]string{},
+	}
+	if len(m.Statuses) == 0 {
+		m.Statuses = DefaultStatusModel.Statuses
+	}
+	for _, sm := range d.Mappings {
+		m.Mappings[sm.From] = sm.To
+	}
+
+	return m
+}
+
+func ReadConfig(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// GetInstances returns the configured jira instances with their credentials, or the default instance from flags
+func GetInstances(f FlagData) ([]j.Instance, error) {
+	var configured []Instance
+	if err := viper.UnmarshalKey("instances", &configured); err != nil {
+		return nil, fmt.Errorf("parsing instances: %w", err)
+	}
+
+	if len(configured) == 0 {
+		if f.OAuth.TokenFile != "" {
+			i, err := j.NewOAuthInstance(cache.DefaultInstance, f.OAuth.TokenFile, j.NewOAuthConfig(f.OAuth.ClientID, f.OAuth.ClientSecret, f.OAuth.Redirect))
+			if err != nil {
+				return nil, err
+			}
+			i.EpicLinkField = f.EpicLinkField
+			i.SprintField = f.SprintField
+			if i.NumberFields, err = numberFields(nil, f); err != nil {
+				return nil, err
+			}
+			return useFixtures(f, []j.Instance{*i}, false), nil
+		}
+
+		if f.Url == "" && f.Replay != "" {
+			f.Url = "file://" + f.Replay
+		}
+		if f.Url == "" {
+			return nil, errors.New("url parameter can't be empty without configured instances")
+		}
+
+		token, err := readToken(f.Token, f.TokenFile, f.TokenCommand)
+		if err != nil {
+			return nil, err
+		}
+
+		i := j.NewInstance(f.Url, f.User, token)
+		i.Deployment = f.Deployment
+		i.EpicLinkField = f.EpicLinkField
+		i.SprintField = f.SprintField
+		if i.NumberFields, err = numberFields(nil, f); err != nil {
+			return nil, err
+		}
+		return useFixtures(f, []j.Instance{i}, false), nil
+	}
+
+	instances := make([]j.Instance, 0, len(configured))
+	names := map[string]bool{}
+	for _, i := range configured {
+		if i.Name == "" || (i.URL == "" && i.OAuthTokenFile == "") {
+			return nil, fmt.Errorf("instances require both a name and url or oauth token file, got %+v", i)
+		}
+		if names[i.Name] {
+			return nil, fmt.Errorf("instance %q is defined more than once", i.Name)
+		}
+		names[i.Name] = true
+
+		if i.OAuthTokenFile != "" {
+			conf := j.NewOAuthConfig(i.OAuthClientID, os.Getenv(i.OAuthClientSecretEnv), f.OAuth.Redirect)
+			inst, err := j.NewOAuthInstance(i.Name, i.OAuthTokenFile, conf)
+			if err != nil {
+				return nil, fmt.Errorf("instance %s: %w", i.Name, err)
+			}
+			inst.EpicLinkField, inst.SprintField = i.customFields(f)
+			if inst.NumberFields, err = numberFields(i.NumberFields, f); err != nil {
+				return nil, fmt.Errorf("instance %s: %w", i.Name, err)
+			}
+			instances = append(instances, *inst)
+			continue
+		}
+
+		user := i.User
+		if i.UserEnv != "" {
+			user = os.Getenv(i.UserEnv)
+		}
+
+		token := ""
+		if i.TokenEnv != "" {
+			token = os.Getenv(i.TokenEnv)
+		}
+		token, err := readToken(token, i.TokenFile, i.TokenCommand)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: %w", i.Name, err)
+		}
+
+		inst := j.NewNamedInstance(i.Name, i.URL, user, token)
+		inst.Deployment = i.Deployment
+		inst.EpicLinkField, inst.SprintField = i.customFields(f)
+		if inst.NumberFields, err = numberFields(i.NumberFields, f); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", i.Name, err)
+		}
+		instances = append(instances, inst)
+	}
+
+	return useFixtures(f, instances, true), nil
+}
+
+// customFields are the instance's epic link and sprint fields, falling back to the flags
+func (i Instance) customFields(f FlagData) (epicLink, sprint string) {
+	epicLink, sprint = i.EpicLinkField, i.SprintField
+	if epicLink == "" {
+		epicLink = f.EpicLinkField
+	}
+	if sprint == "" {
+		sprint = f.SprintField
+	}
+	return epicLink, sprint
+}
+
+// numberFields are the configured number fields followed by those from --number-field that aren't configured
+func numberFields(configured []NumberFieldMapping, f FlagData) ([]j.NumberField, error) {
+	var fields []j.NumberField
+	names := map[string]bool{}
+	add := func(name, id string) error {
+		if name == "" || id == "" {
+			return fmt.Errorf("number fields require both a name and field id, got %q=%q", name, id)
+		}
+		if !names[name] {
+			names[name] = true
+			fields = append(fields, j.NumberField{Name: name, ID: id})
+		}
+		return nil
+	}
+
+	for _, m := range configured {
+		if err := add(m.Name, m.Field); err != nil {
+			return nil, err
+		}
+	}
+	for _, nf := range f.NumberFields {
+		name, id, _ := strings.Cut(nf, "=")
+		if err := add(strings.TrimSpace(name), strings.TrimSpace(id)); err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
+
+// useFixtures points instances at recorded responses rather than jira for --replay or a file:// url, and records them
+// with --record. with configured instances each gets its own sub directory
+func useFixtures(f FlagData, instances []j.Instance, perInstance bool) []j.Instance {
+	dir := func(base string, i j.Instance) string {
+		if perInstance {
+			return filepath.Join(base, i.Name)
+		}
+		return base
+	}
+
+	for n := range instances {
+		i := &instances[n]
+
+		switch {
+		case strings.HasPrefix(i.URL, "file://"):
+			i.Replay(strings.TrimPrefix(i.URL, "file://"))
+		case f.Replay != "":
+			i.Replay(dir(f.Replay, *i))
+		case f.Record != "":
+			i.Record(dir(f.Record, *i))
+			continue
+		default:
+			continue
+		}
+
+		// fixtures are named by path so requests have to look the same whatever the url was when they were recorded
+		i.APIURL = "file://replay"
+		i.TokenSource = nil
+	}
+
+	return instances
+}
+
+// readToken returns the token read from a file or the output of a command when either is set, so it can come from a
+// secrets manager rather than the environment. both are read on every call so rotated tokens are picked up by daemon
+func readToken(token, file, command string) (string, error) {
+	switch {
+	case file != "":
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("reading token file: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+
+	case command != "":
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("running token command: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	return token, nil
+}
+
+// GetInstance returns the named instance
+func GetInstance(instances []j.Instance, name string) (*j.Instance, error) {
+	for _, i := range instances {
+		if i.Name == name {
+			return &i, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown instance %q", name)
+}
+
+// GetDatasets returns the configured datasets, or the default dataset from --jql
+func GetDatasets(f FlagData) ([]Dataset, error) {
+	var datasets []Dataset
+	if err := viper.UnmarshalKey("datasets", &datasets); err != nil {
+		return nil, fmt.Errorf("parsing datasets: %w", err)
+	}
+
+	// datasets default to the only configured instance, or the one from flags
+	defaultInstance := cache.DefaultInstance
+	var instances []Instance
+	if err := viper.UnmarshalKey("instances", &instances); err != nil {
+		return nil, fmt.Errorf("parsing instances: %w", err)
+	}
+	if len(instances) == 1 {
+		defaultInstance = instances[0].Name
+	}
+
+	if len(datasets) == 0 {
+		return []Dataset{{Name: cache.DefaultDataset, Instance: defaultInstance, JQL: f.JQL}}, nil
+	}
+
+	names := map[string]bool{}
+	for n, d := range datasets {
+		if d.Name == "" || d.JQL == "" {
+			return nil, fmt.Errorf("datasets require both a name and jql, got %+v", d)
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("dataset %q is defined more than once", d.Name)
+		}
+		names[d.Name] = true
+
+		if d.Instance == "" {
+			datasets[n].Instance = defaultInstance
+		}
+	}
+
+	return datasets, nil
+}
+
+// GetDataset returns the dataset selected with --dataset, or the default when none is selected
+func GetDataset(f FlagData) (*Dataset, error) {
+	datasets, err := GetDatasets(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Dataset == "" {
+		return &Dataset{}, nil
+	}
+
+	for _, d := range datasets {
+		if d.Name == f.Dataset {
+			return &d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown dataset %q", f.Dataset)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"golang.org/x/oauth2"
+)
+
+// oauthTokenFile saves an oauth token as the auth command does, for an instance without configured instances
+func oauthTokenFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	token := j.OAuthToken{Token: oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}, CloudID: "cloud-id", SiteURL: "https://example.atlassian.net"}
+	if err := j.SaveOAuthToken(path, &token); err != nil {
+		t.Fatalf("saving oauth token: %v", err)
+	}
+	return path
+}
+
+func TestGetInstancesOAuthRecords(t *testing.T) {
+	t.Parallel()
+
+	instances, err := GetInstances(FlagData{OAuth: OAuthFlags{TokenFile: oauthTokenFile(t)}, Record: t.TempDir()})
+	if err != nil {
+		t.Fatalf("getting instances: %v", err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("expected the default oauth instance, got %+v", instances)
+	}
+	if i := instances[0]; i.Client == nil || i.TokenSource == nil || i.APIURL != j.AtlassianAPIURL+"cloud-id" {
+		t.Errorf("expected the oauth instance to record its responses, got %+v", i)
+	}
+}
+
+func TestGetInstancesOAuthReplays(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "replay.db")
+	f := FlagData{OAuth: OAuthFlags{TokenFile: oauthTokenFile(t)}, Replay: fixturesDir, JQL: "project = AB", CachePath: path}
+	if err := RunFetch(context.Background(), f); err != nil {
+		t.Fatalf("replaying %s: %v", fixturesDir, err)
+	}
+
+	theCache, err := cache.Open(path)
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		t.Fatalf("reading issues: %v", err)
+	}
+	if len(*issues) != 2 {
+		t.Errorf("expected the 2 issues replayed rather than fetched from jira, got %d", len(*issues))
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+// StatusCategories are the categories jira groups every status into, in burn-up stack order
+var StatusCategories = []string{"Done", "In Progress", "To Do"}
+
+// StatusCategory is the category of a status, from jira when fetched otherwise guessed from the status model
+func StatusCategory(categories map[string]string, model StatusModel, instance, status string) string {
+	if category, ok := categories[instance+"/"+status]; ok {
+		return category
+	}
+
+	switch status {
+	case "Closed", "Done", "Resolved":
+		return "Done"
+	}
+
+	switch model.Normalise(status) {
+	case "In Progress", "In Review":
+		return "In Progress"
+	}
+
+	return "To Do"
+}
+
+// IsSubTask is true for sub-tasks, which roll up into their parent issue rather than its epic
+func IsSubTask(i cache.Issue) bool {
+	t := strings.ToLower(i.Type)
+	return t == "sub-task" || t == "subtask"
+}
+
+// Epic is an epic and the issues directly in it, sub-tasks excluded
+type Epic struct {
+	Instance string
+	Key      string
+	Issue    *cache.Issue // nil when the epic itself isn't cached, such as one in another project
+	Children []cache.Issue
+}
+
+// FindEpics groups issues under their epics, every cached epic and any uncached parent of an issue that isn't a
+// sub-task, ordered by key
+func FindEpics(issues []cache.Issue) []Epic {
+	byKey := map[string]*cache.Issue{}
+	for n, i := range issues {
+		byKey[i.Instance+"/"+i.Key] = &issues[n]
+	}
+
+	epics := map[string]*Epic{}
+	for n, i := range issues {
+		if i.Type == "Epic" {
+			epics[i.Instance+"/"+i.Key] = &Epic{Instance: i.Instance, Key: i.Key, Issue: &issues[n]}
+		}
+	}
+
+	for _, i := range issues {
+		if i.Parent == "" || IsSubTask(i) {
+			continue
+		}
+
+		k := i.Instance + "/" + i.Parent
+		if epics[k] == nil {
+			if p := byKey[k]; p != nil {
+				continue // the parent is cached and isn't an epic
+			}
+			epics[k] = &Epic{Instance: i.Instance, Key: i.Parent}
+		}
+		epics[k].Children = append(epics[k].Children, i)
+	}
+
+	result := make([]Epic, 0, len(epics))
+	for _, e := range epics {
+		sort.Slice(e.Children, func(a, b int) bool {
+			return e.Children[a].Key < e.Children[b].Key
+		})
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Instance != result[b].Instance {
+			return result[a].Instance < result[b].Instance
+		}
+		return result[a].Key < result[b].Key
+	})
+
+	return result
+}
+
+// EpicProgress is how many of an epic's issues are in each status category
+type EpicProgress struct {
+	Instance    string  `json:"instance"`
+	Key         string  `json:"key"`
+	URL         string  `json:"url,omitempty"`     // empty when the epic isn't cached
+	Summary     string  `json:"summary,omitempty"` // empty when the epic isn't cached
+	Status      string  `json:"status,omitempty"`  // empty when the epic isn't cached
+	Children    int     `json:"children"`
+	ToDo        int     `json:"to_do"`
+	InProgress  int     `json:"in_progress"`
+	Done        int     `json:"done"`
+	PercentDone float64 `json:"percent_done"`
+}
+
+// CalcEpicProgress counts each epic's issues by the category of their current status
+func CalcEpicProgress(categories map[string]string, model StatusModel, epics []Epic) []EpicProgress {
+	result := make([]EpicProgress, 0, len(epics))
+	for _, e := range epics {
+		p := EpicProgress{Instance: e.Instance, Key: e.Key, Children: len(e.Children)}
+		if e.Issue != nil {
+			p.URL = e.Issue.URL
+			p.Summary = e.Issue.Summary
+			p.Status = e.Issue.Status
+		}
+
+		for _, i := range e.Children {
+			switch StatusCategory(categories, model, i.Instance, i.Status) {
+			case "Done":
+				p.Done++
+			case "In Progress":
+				p.InProgress++
+			default:
+				p.ToDo++
+			}
+		}
+		if p.Children > 0 {
+			p.PercentDone = float64(p.Done) * 100 / float64(p.Children)
+		}
+
+		result = append(result, p)
+	}
+
+	return result
+}
+
+type DailyCategoryCounts struct {
+	Date       time.Time
+	Categories map[string]float64
+}
+
+// CalcEpicBurnUp counts an epic's issues in each status category at the end of every day in the range by replaying
+// their status events, each counted from the day it was created weighted by its value that day
+func CalcEpicBurnUp(theCache *cache.Cache, categories map[string]string, model StatusModel, w *Weight, e Epic, from, to time.Time) ([]DailyCategoryCounts, error) {
+	var days []DailyCategoryCounts
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, DailyCategoryCounts{Date: day, Categories: map[string]float64{}})
+	}
+
+	for _, i := range e.Children {
+		events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "status")
+		if err != nil {
+			return nil, fmt.Errorf("getting events for %s: %w", i.Key, err)
+		}
+
+		status := i.Status
+		if len(events) > 0 {
+			status = events[0].From
+		}
+
+		n := 0
+		for d := range days {
+			end := days[d].Date.AddDate(0, 0, 1)
+			if !i.Created.Before(end) {
+				continue
+			}
+
+			for n < len(events) && events[n].Date.Before(end) {
+				status = events[n].To
+				n++
+			}
+			days[d].Categories[StatusCategory(categories, model, i.Instance, status)] += w.At(i, end)
+		}
+	}
+
+	return days, nil
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"net/http"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/clog"
+	"github.com/katbyte/gogo-jira-stats/lib/prom"
+)
+
+// buckets in days for the lead and cycle time histograms
+var flowDayBuckets = []float64{1, 2, 5, 10, 20, 30, 60, 90, 180, 365}
+
+// MetricsHandler computes prometheus metrics from the cache on every scrape
+func MetricsHandler(theCache *cache.Cache, model StatusModel) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		metrics, err := BuildPrometheusMetrics(theCache, model)
+		if err != nil {
+			clog.Log.Errorf("building metrics: %v", err)
+			http.Error(w, "failed to build metrics", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := prom.Write(w, metrics...); err != nil {
+			clog.Log.Errorf("writing metrics: %v", err)
+		}
+	}
+}
+
+func BuildPrometheusMetrics(theCache *cache.Cache, model StatusModel) ([]*prom.Metric, error) {
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	flows, err := CalcIssueFlows(theCache, model, *issues)
+	if err != nil {
+		return nil, err
+	}
+
+	// prometheus sets instance to the scrape target, so the jira instance is jira_instance
+	openByStatus := prom.NewGauge("jira_issues_open", "Number of open issues by jira instance, status and type.")
+	openByLabel := prom.NewGauge("jira_issues_open_by_label", "Number of open issues by label.")
+	// these are recomputed from the cache on each scrape and drop when issues leave it, so they are gauges not counters
+	created := prom.NewGauge("jira_issues_created", "Number of issues created by type.")
+	closed := prom.NewGauge("jira_issues_closed", "Number of issues closed by type.")
+	leadTime := prom.NewHistogram("jira_issue_lead_time_days", "Days from created to closed for closed issues.", flowDayBuckets)
+	cycleTime := prom.NewHistogram("jira_issue_cycle_time_days", "Days from first in progress to closed for closed issues.", flowDayBuckets)
+
+	type statusType struct{ instance, status, group, issueType string }
+	openCounts := map[statusType]int{}
+	labelCounts := map[string]int{}
+	createdCounts := map[string]int{}
+	closedCounts := map[string]int{}
+
+	for _, f := range flows {
+		i := f.Issue
+		createdCounts[i.Type]++
+
+		if f.Closed == nil {
+			openCounts[statusType{i.Instance, i.Status, model.Normalise(i.Status), i.Type}]++
+			for _, l := range i.Labels {
+				if l != "" {
+					labelCounts[l]++
+				}
+			}
+			continue
+		}
+
+		closedCounts[i.Type]++
+		leadTime.Observe(f.LeadDays(), prom.Labels{"type": i.Type})
+		if f.Started != nil {
+			cycleTime.Observe(f.CycleDays(), prom.Labels{"type": i.Type})
+		}
+	}
+
+	for k, n := range openCounts {
+		openByStatus.Set(float64(n), prom.Labels{"jira_instance": k.instance, "status": k.status, "status_group": k.group, "type": k.issueType})
+	}
+	for l, n := range labelCounts {
+		openByLabel.Set(float64(n), prom.Labels{"label": l})
+	}
+	for t, n := range createdCounts {
+		created.Set(float64(n), prom.Labels{"type": t})
+	}
+	for t, n := range closedCounts {
+		closed.Set(float64(n), prom.Labels{"type": t})
+	}
+
+	// fetch health
+	syncs, err := theCache.GetSyncStats()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := prom.NewCounter("jira_fetch_runs_total", "Number of fetch runs recorded in the cache.")
+	runs.Set(float64(syncs.Runs), nil)
+	fetchErrors := prom.NewCounter("jira_fetch_errors_total", "Number of fetch runs that failed.")
+	fetchErrors.Set(float64(syncs.Errors), nil)
+
+	lastSuccess := prom.NewGauge("jira_fetch_last_success_timestamp_seconds", "Unix time the last successful fetch started.")
+	lastDuration := prom.NewGauge("jira_fetch_last_duration_seconds", "Duration of the last fetch run.")
+	lastIssues := prom.NewGauge("jira_fetch_last_issues", "Number of issues processed by the last fetch run.")
+	lastFailed := prom.NewGauge("jira_fetch_last_failed", "1 if the last fetch run failed.")
+	if s := syncs.LastSuccess; s != nil {
+		lastSuccess.Set(float64(s.Started.Unix()), nil)
+	}
+	if s := syncs.Last; s != nil {
+		lastDuration.Set(s.Duration.Seconds(), nil)
+		lastIssues.Set(float64(s.Issues), nil)
+		failed := 0.0
+		if !s.Succeeded() {
+			failed = 1
+		}
+		lastFailed.Set(failed, nil)
+	}
+
+	return []*prom.Metric{
+		openByStatus, openByLabel, created, closed, leadTime, cycleTime,
+		runs, fetchErrors, lastSuccess, lastDuration, lastIssues, lastFailed,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+	"github.com/katbyte/gogo-jira-stats/lib/prom"
+)
+
+func TestPrometheusMetricsJiraInstanceLabel(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issue := jiratest.NewIssue("AB-1", "To Do", created)
+	if err := theCache.UpsertIssueFromJIRA("work", issue.IssueScheme); err != nil {
+		t.Fatalf("caching AB-1: %v", err)
+	}
+
+	metrics, err := BuildPrometheusMetrics(theCache, DefaultStatusModel)
+	if err != nil {
+		t.Fatalf("building metrics: %v", err)
+	}
+	var b bytes.Buffer
+	if err := prom.Write(&b, metrics...); err != nil {
+		t.Fatalf("writing metrics: %v", err)
+	}
+
+	// instance is the label prometheus gives the scrape target
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "jira_issues_open{") {
+			if !strings.Contains(line, `jira_instance="work"`) || strings.Contains(line, `{instance=`) || strings.Contains(line, `,instance=`) {
+				t.Errorf("expected the open issues to be labelled with their jira_instance, got %s", line)
+			}
+			return
+		}
+	}
+	t.Errorf("expected a jira_issues_open series, got\n%s", b.String())
+}
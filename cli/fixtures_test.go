@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+// the checked in fixtures are recorded from recordFixtures, go test ./cli -run TestReplay -update records them again
+var update = flag.Bool("update", false, "record testdata/fixtures again from the fake jira")
+
+const fixturesDir = "testdata/fixtures"
+
+// recordFixtures fetches a small project from the fake jira, recording its responses to dir
+func recordFixtures(t *testing.T, dir string) {
+	t.Helper()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.ChangelogLimit = 1
+
+	s.AddIssues(
+		jiratest.NewIssue("AB-1", "To Do", created).
+			Transition(created.Add(24*time.Hour), "To Do", "In Progress").
+			Transition(created.Add(72*time.Hour), "In Progress", "Closed"),
+		jiratest.NewIssue("AB-2", "To Do", created.Add(24*time.Hour)).
+			Transition(created.Add(48*time.Hour), "To Do", "In Progress"),
+	)
+
+	f := FlagData{Url: s.URL, User: jiratest.User, Token: jiratest.Token, JQL: "project = AB", Record: dir, CachePath: filepath.Join(t.TempDir(), "record.db")}
+	if err := RunFetch(context.Background(), f); err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+}
+
+// replayFixtures fetches from the fixtures in dir into a new cache, checking the issues, their events and their cycle
+// time came through
+func replayFixtures(t *testing.T, dir string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "replay.db")
+	if err := RunFetch(context.Background(), FlagData{Replay: dir, JQL: "project = AB", CachePath: path}); err != nil {
+		t.Fatalf("replaying %s: %v", dir, err)
+	}
+
+	theCache, err := cache.Open(path)
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issues, err := theCache.GetAllIssues()
+	if err != nil {
+		t.Fatalf("reading issues: %v", err)
+	}
+	if len(*issues) != 2 {
+		t.Fatalf("expected 2 cached issues, got %d", len(*issues))
+	}
+
+	events, err := theCache.GetIssueEvents(cache.DefaultInstance, "AB-1")
+	if err != nil {
+		t.Fatalf("reading AB-1 events: %v", err)
+	}
+	if len(events) != 2 || events[0].To != "In Progress" || events[1].To != "Closed" {
+		t.Fatalf("expected AB-1 to go to In Progress then Closed, got %+v", events)
+	}
+
+	flows, err := CalcIssueFlows(theCache, DefaultStatusModel, *issues)
+	if err != nil {
+		t.Fatalf("calculating flows: %v", err)
+	}
+	cycle := map[string]float64{}
+	for _, f := range flows {
+		cycle[f.Issue.Key] = f.CycleDays()
+	}
+	if cycle["AB-1"] != 2 || cycle["AB-2"] != 0 {
+		t.Errorf("expected AB-1 to take 2 days and AB-2 to still be open, got %v", cycle)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	recordFixtures(t, dir)
+
+	recorded, err := os.ReadDir(dir)
+	if err != nil || len(recorded) == 0 {
+		t.Fatalf("expected fixtures to be recorded to %s: %v", dir, err)
+	}
+
+	replayFixtures(t, dir)
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	if *update {
+		if err := os.RemoveAll(fixturesDir); err != nil {
+			t.Fatal(err)
+		}
+		recordFixtures(t, fixturesDir)
+	}
+
+	replayFixtures(t, fixturesDir)
+}
@@ -3,22 +3,55 @@ package cli
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type FlagData struct {
-	Url       string
-	User      string
-	Token     string
-	JQL       string
-	Fields    []string
-	Expand    []string
-	CachePath string
+	Url            string
+	User           string
+	Token          string
+	TokenFile      string
+	TokenCommand   string
+	OAuth          OAuthFlags
+	Deployment     string
+	Record         string
+	Replay         string
+	JQL            string
+	Fields         []string
+	Expand         []string
+	EpicLinkField  string
+	SprintField    string
+	NumberFields   []string
+	Weight         string
+	Sample         int
+	CachePath      string
+	ConfigPath     string
+	Dataset        string
+	Instance       string
+	Labels         []string
+	Components     []string
+	FixVersions    []string
+	Output         string
+	Format         string
+	File           string
+	IncludeMissing bool
+	Addr           string
+	Schedule       string
+	Jitter         time.Duration
+	WebhookSecret  string
 	// FullFetch bool // not important for jira?
 }
 
+type OAuthFlags struct {
+	ClientID     string
+	ClientSecret string
+	TokenFile    string
+	Redirect     string
+}
+
 func configureFlags(root *cobra.Command) error {
 	flags := FlagData{}
 	pflags := root.PersistentFlags()
@@ -26,20 +59,83 @@ func configureFlags(root *cobra.Command) error {
 	pflags.StringVarP(&flags.Url, "url", "", "", "jira instance url")
 	pflags.StringVarP(&flags.User, "user", "u", "", "jira user")
 	pflags.StringVarP(&flags.Token, "token", "t", "", "jira oauth token (JIRA_TOKEN)")
+	pflags.StringVarP(&flags.TokenFile, "token-file", "", "", "read the jira token from a file instead (JIRA_TOKEN_FILE)")
+	pflags.StringVarP(&flags.TokenCommand, "token-command", "", "", "read the jira token from the output of a shell command instead, such as a secrets manager cli (JIRA_TOKEN_COMMAND)")
+	pflags.StringVarP(&flags.OAuth.ClientID, "oauth-client-id", "", "", "oauth 2.0 (3LO) app client id (JIRA_OAUTH_CLIENT_ID)")
+	pflags.StringVarP(&flags.OAuth.ClientSecret, "oauth-client-secret", "", "", "oauth 2.0 (3LO) app client secret (JIRA_OAUTH_CLIENT_SECRET)")
+	pflags.StringVarP(&flags.OAuth.TokenFile, "oauth-token-file", "", "", "path the oauth token is stored and refreshed in, when set it is used instead of --url, --user and --token (JIRA_OAUTH_TOKEN_FILE)")
+	pflags.StringVarP(&flags.OAuth.Redirect, "oauth-redirect", "", "http://localhost:8085/callback", "oauth callback url registered with the app, auth listens on it")
+	pflags.StringVarP(&flags.Deployment, "deployment", "", "auto", "jira deployment, cloud, server (includes data center) or auto to detect it (JIRA_DEPLOYMENT)")
 	pflags.StringVarP(&flags.JQL, "jql", "q", "", "jira jql query to list all issues")
 	pflags.StringSliceVarP(&flags.Fields, "fields", "f", nil, "jira fields to fetch separated by commas")
 	pflags.StringSliceVarP(&flags.Expand, "expand", "e", nil, "jira fields to expand separated by commas")
+	pflags.StringVarP(&flags.EpicLinkField, "epic-link-field", "", "", "id of the classic Epic Link custom field such as customfield_10014, discovered when empty (JIRA_EPIC_LINK_FIELD)")
+	pflags.StringVarP(&flags.SprintField, "sprint-field", "", "", "id of the sprint custom field such as customfield_10020, discovered when empty (JIRA_SPRINT_FIELD)")
+	pflags.StringSliceVarP(&flags.NumberFields, "number-field", "", nil, "numeric custom fields to fetch as name=id such as story_points=customfield_10016 separated by commas, story_points is discovered when not given (JIRA_NUMBER_FIELDS)")
+	pflags.StringVarP(&flags.Weight, "weight", "", "count", "weight daily, throughput and sprint metrics by count of issues or the sum of a number field such as story_points")
+	pflags.IntVarP(&flags.Sample, "sample", "", 0, "fields samples values from this many issues matching the jql")
+	pflags.StringVarP(&flags.Record, "record", "", "", "save the raw jira responses to this directory while fetching")
+	pflags.StringVarP(&flags.Replay, "replay", "", "", "fetch from responses saved with --record in this directory instead of jira, as does a file:// url (JIRA_REPLAY)")
 	pflags.StringVarP(&flags.CachePath, "cache", "c", "", "path to sqllite3 db to use as cache")
+	pflags.StringVarP(&flags.ConfigPath, "config", "", "", "path to a config file (yaml, json or toml) for any flag plus datasets (GOGO_JIRA_STATS_CONFIG)")
+	pflags.StringVarP(&flags.Instance, "instance", "", "", "limit graphs and reports to a jira instance from the config file, or the instance import adds issues to (JIRA_INSTANCE)")
+	pflags.StringVarP(&flags.Dataset, "dataset", "d", "", "limit graphs and reports to a dataset from the config file, or the dataset import adds issues to (JIRA_DATASET)")
+	pflags.StringSliceVarP(&flags.Labels, "label", "", nil, "limit graphs, reports and queries to issues with any of these labels separated by commas")
+	pflags.StringSliceVarP(&flags.Components, "component", "", nil, "limit graphs, reports and queries to issues in any of these components separated by commas")
+	pflags.StringSliceVarP(&flags.FixVersions, "fix-version", "", nil, "limit graphs, reports and queries to issues with any of these fix versions separated by commas")
+	pflags.BoolVarP(&flags.IncludeMissing, "include-missing", "", false, "include issues no longer returned by the jql (deleted or moved) in graphs and reports")
+	pflags.StringVarP(&flags.Output, "output", "o", "text", "report, query and issue output format, text or json, query also takes keys")
+	pflags.StringVarP(&flags.Format, "format", "", "csv", "export format, csv, jsonl or parquet")
+	pflags.StringVarP(&flags.File, "file", "", "", "file to export to, defaults to stdout")
+	pflags.StringVarP(&flags.Addr, "addr", "", ":8080", "address for serve to listen on (SERVE_ADDR)")
+	pflags.StringVarP(&flags.Schedule, "schedule", "", "*/2 * * * *", "daemon sync schedule as a cron expression or interval such as 5m (SYNC_CRON)")
+	pflags.StringVarP(&flags.WebhookSecret, "webhook-secret", "", "", "shared secret jira webhooks are signed with, serve only accepts webhooks when set (JIRA_WEBHOOK_SECRET)")
+	pflags.DurationVarP(&flags.Jitter, "jitter", "", 0, "random delay of up to this duration added to each daemon run (SYNC_JITTER)")
+
+	// fetch always requests the fields it caches, these are kept so existing scripts still run
+	for _, name := range []string{"fields", "expand"} {
+		if err := pflags.MarkDeprecated(name, "fetch requests the fields it needs"); err != nil {
+			return fmt.Errorf("error deprecating '%s' flag: %w", name, err)
+		}
+	}
 
 	// binding map for viper/pflag -> env
 	m := map[string]string{
-		"url":    "JIRA_URL",
-		"user":   "JIRA_USER",
-		"jql":    "JIRA_JQL",
-		"token":  "JIRA_TOKEN",
-		"fields": "JIRA_FIELDS",
-		"expand": "JIRA_EXPAND",
-		"cache":  "CACHE_DB_FILE",
+		"url":                 "JIRA_URL",
+		"user":                "JIRA_USER",
+		"jql":                 "JIRA_JQL",
+		"token":               "JIRA_TOKEN",
+		"deployment":          "JIRA_DEPLOYMENT",
+		"token-file":          "JIRA_TOKEN_FILE",
+		"token-command":       "JIRA_TOKEN_COMMAND",
+		"oauth-client-id":     "JIRA_OAUTH_CLIENT_ID",
+		"oauth-client-secret": "JIRA_OAUTH_CLIENT_SECRET",
+		"oauth-token-file":    "JIRA_OAUTH_TOKEN_FILE",
+		"oauth-redirect":      "",
+		"record":              "",
+		"replay":              "JIRA_REPLAY",
+		"fields":              "JIRA_FIELDS",
+		"expand":              "JIRA_EXPAND",
+		"epic-link-field":     "JIRA_EPIC_LINK_FIELD",
+		"sprint-field":        "JIRA_SPRINT_FIELD",
+		"number-field":        "JIRA_NUMBER_FIELDS",
+		"weight":              "",
+		"sample":              "",
+		"cache":               "CACHE_DB_FILE",
+		"config":              "GOGO_JIRA_STATS_CONFIG",
+		"dataset":             "JIRA_DATASET",
+		"instance":            "JIRA_INSTANCE",
+		"label":               "JIRA_LABEL",
+		"component":           "JIRA_COMPONENT",
+		"fix-version":         "JIRA_FIX_VERSION",
+		"output":              "",
+		"format":              "",
+		"file":                "",
+		"addr":                "SERVE_ADDR",
+		"schedule":            "SYNC_CRON",
+		"jitter":              "SYNC_JITTER",
+		"webhook-secret":      "JIRA_WEBHOOK_SECRET",
+		"include-missing":     "",
 	}
 
 	for name, env := range m {
@@ -58,25 +154,54 @@ func configureFlags(root *cobra.Command) error {
 }
 
 func GetFlags() FlagData {
-	// for some reason we don't get a proper array back from viper for authors so fix it like this for now TODO FIX
-	fields := viper.GetStringSlice("fields")
-	if len(fields) != 0 {
-		fields = strings.Split(fields[0], ",")
-	}
-
-	expand := viper.GetStringSlice("expand")
-	if len(expand) != 0 {
-		expand = strings.Split(expand[0], ",")
-	}
-
 	// there has to be an easier way....
 	return FlagData{
-		Url:       viper.GetString("url"),
-		User:      viper.GetString("user"),
-		Token:     viper.GetString("token"),
-		JQL:       viper.GetString("jql"),
-		Fields:    fields,
-		Expand:    expand,
-		CachePath: viper.GetString("cache"),
+		Url:          viper.GetString("url"),
+		User:         viper.GetString("user"),
+		Token:        viper.GetString("token"),
+		TokenFile:    viper.GetString("token-file"),
+		TokenCommand: viper.GetString("token-command"),
+		OAuth: OAuthFlags{
+			ClientID:     viper.GetString("oauth-client-id"),
+			ClientSecret: viper.GetString("oauth-client-secret"),
+			TokenFile:    viper.GetString("oauth-token-file"),
+			Redirect:     viper.GetString("oauth-redirect"),
+		},
+		Deployment:     viper.GetString("deployment"),
+		Record:         viper.GetString("record"),
+		Replay:         viper.GetString("replay"),
+		JQL:            viper.GetString("jql"),
+		Fields:         getStringSlice("fields"),
+		Expand:         getStringSlice("expand"),
+		EpicLinkField:  viper.GetString("epic-link-field"),
+		SprintField:    viper.GetString("sprint-field"),
+		NumberFields:   getStringSlice("number-field"),
+		Weight:         viper.GetString("weight"),
+		Sample:         viper.GetInt("sample"),
+		CachePath:      viper.GetString("cache"),
+		ConfigPath:     viper.GetString("config"),
+		Dataset:        viper.GetString("dataset"),
+		Instance:       viper.GetString("instance"),
+		Labels:         getStringSlice("label"),
+		Components:     getStringSlice("component"),
+		FixVersions:    getStringSlice("fix-version"),
+		Output:         viper.GetString("output"),
+		Format:         viper.GetString("format"),
+		File:           viper.GetString("file"),
+		IncludeMissing: viper.GetBool("include-missing"),
+		Addr:           viper.GetString("addr"),
+		Schedule:       viper.GetString("schedule"),
+		Jitter:         viper.GetDuration("jitter"),
+		WebhookSecret:  viper.GetString("webhook-secret"),
+	}
+}
+
+// for some reason we don't always get a proper array back from viper for slices, env vars come back as one value, so
+// split every value on commas. TODO FIX
+func getStringSlice(name string) []string {
+	var values []string
+	for _, v := range viper.GetStringSlice(name) {
+		values = append(values, strings.Split(v, ",")...)
 	}
+	return values
 }
@@ -0,0 +1,414 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+// StatusModel defines the statuses we graph (in stack order) and how freeform jira statuses map onto them
+type StatusModel struct {
+	Statuses []string
+	Mappings map[string]string
+}
+
+var DefaultStatusModel = StatusModel{
+	Statuses: []string{"Other", "Accepted", "Awaiting Prioritisation", "Pending Triage", "Blocked", "Needs More Info", "To Do", "Prioritised", "In Progress", "In Review"},
+	Mappings: map[string]string{
+		"In Development":             "In Progress",
+		"Accepted":                   "To Do",
+		"Need More Information":      "Needs More Info",
+		"R&D to Investigate Further": "Awaiting Prioritisation",
+		"Security Triage":            "Pending Triage",
+		"Under Review by R&D PM":     "In Review",
+	},
+}
+
+func (m StatusModel) Has(status string) bool {
+	for _, s := range m.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalise applies the mappings and falls back to Other for any status not in the model
+func (m StatusModel) Normalise(status string) string {
+	if status == "Closed" {
+		return status
+	}
+
+	if s, ok := m.Mappings[status]; ok {
+		status = s
+	}
+
+	if !m.Has(status) {
+		return "Other"
+	}
+
+	return status
+}
+
+type DailyOpenIssues struct {
+	Date     time.Time
+	Total    float64
+	Statuses map[string]float64 // jira status are freeform, so lets just allow any
+}
+
+type DailyOpenIssuesResult struct {
+	Days           []DailyOpenIssues // sorted by date
+	SkippedAfterTo int
+	ClosedCount    int
+	OtherCount     int
+}
+
+// CalcDailyOpenIssues replays the status events of each issue day by day to count how many were open in each status,
+// each weighted by its value at the end of the day
+func CalcDailyOpenIssues(theCache *cache.Cache, model StatusModel, w *Weight, issues []cache.Issue, from, to time.Time) (*DailyOpenIssuesResult, error) {
+	result := DailyOpenIssuesResult{}
+
+	dates := map[string]DailyOpenIssues{}
+	for day := from.AddDate(0, 0, -1); day.Before(to.AddDate(0, 0, 1)); day = day.AddDate(0, 0, 1) {
+		k := day.Format("2006-01-02")
+		dates[k] = DailyOpenIssues{
+			Date:     day,
+			Statuses: map[string]float64{},
+		}
+
+		for _, status := range model.Statuses {
+			dates[k].Statuses[status] = 0
+		}
+	}
+
+	for _, i := range issues {
+		opened := time.Date(i.Created.Year(), i.Created.Month(), i.Created.Day(), 0, 0, 0, 0, time.UTC)
+
+		if opened.After(to) {
+			result.SkippedAfterTo++
+			continue
+		}
+
+		// figure out timeline of events that matter
+		events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "status")
+		if err != nil {
+			return nil, fmt.Errorf("getting events for %s: %w", i.Key, err)
+		}
+
+		// figure out initial status before any events
+		status := "Other"
+		if len(events) == 0 {
+			// no events, use current status (unless closed)
+			if i.Status != "Closed" {
+				status = i.Status
+			}
+		} else {
+			// use the first event's From field as the initial status
+			status = events[0].From
+		}
+		// apply mappings and fallback to Other
+		status = model.Normalise(status)
+
+		// for each day from open to closed (or now) count this issue using the above array to figure out its "state"
+		// by playing back events to "set the state" until the events
+		eventIndex := 0
+		for day := opened; ; day = day.AddDate(0, 0, 1) {
+			// go through all events for "today" and set the status
+			for ; eventIndex < len(events) && events[eventIndex].Date.Before(day.AddDate(0, 0, 1)); eventIndex++ {
+				status = model.Normalise(events[eventIndex].To)
+				if status == "Closed" {
+					break
+				}
+			}
+
+			// skip data before the "from" date, but here so status is updated
+			if day.Before(from.AddDate(0, 0, -1)) {
+				continue
+			}
+
+			k := day.Format("2006-01-02")
+			dayData := dates[k]
+			weight := w.At(i, day.AddDate(0, 0, 1))
+			dayData.Total += weight
+			dayData.Statuses[status] += weight
+			dates[k] = dayData
+
+			if day.After(to.AddDate(0, 0, -1)) {
+				break
+			}
+
+			// if closed we're done
+			if status == "Closed" {
+				result.ClosedCount++
+				break
+			}
+		}
+
+		if status == "Other" {
+			result.OtherCount++
+		}
+	}
+
+	sortedDays := make([]string, 0, len(dates))
+	for day := range dates {
+		sortedDays = append(sortedDays, day)
+	}
+	sort.Strings(sortedDays)
+
+	for _, date := range sortedDays {
+		day := dates[date]
+
+		if day.Date.Before(from.AddDate(0, 0, -1)) {
+			continue
+		}
+
+		if day.Date.After(to) {
+			continue
+		}
+
+		result.Days = append(result.Days, day)
+	}
+
+	return &result, nil
+}
+
+// IssueFlow is the derived lifecycle of a single issue from its status events
+type IssueFlow struct {
+	Issue   cache.Issue
+	Started *time.Time // first time it entered In Progress
+	Closed  *time.Time // last time it entered Closed, nil if still open
+}
+
+func (f IssueFlow) LeadDays() float64 {
+	if f.Closed == nil {
+		return 0
+	}
+	return f.Closed.Sub(f.Issue.Created).Hours() / 24
+}
+
+func (f IssueFlow) CycleDays() float64 {
+	if f.Closed == nil || f.Started == nil {
+		return 0
+	}
+	return f.Closed.Sub(*f.Started).Hours() / 24
+}
+
+// CalcIssueFlows works out when each issue was started and closed
+func CalcIssueFlows(theCache *cache.Cache, model StatusModel, issues []cache.Issue) ([]IssueFlow, error) {
+	flows := make([]IssueFlow, 0, len(issues))
+
+	for _, i := range issues {
+		events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "status")
+		if err != nil {
+			return nil, fmt.Errorf("getting events for %s: %w", i.Key, err)
+		}
+
+		flows = append(flows, CalcIssueFlow(model, i, events))
+	}
+
+	return flows, nil
+}
+
+// CalcIssueFlow works out when an issue was started and closed from its status events
+func CalcIssueFlow(model StatusModel, i cache.Issue, events []cache.Event) IssueFlow {
+	f := IssueFlow{Issue: i}
+	for _, e := range events {
+		switch model.Normalise(e.To) {
+		case "In Progress":
+			if f.Started == nil {
+				d := e.Date
+				f.Started = &d
+			}
+		case "Closed":
+			d := e.Date
+			f.Closed = &d
+		}
+	}
+
+	// reopened issues are not closed, whatever the history says
+	if !i.IsClosed() {
+		f.Closed = nil
+	} else if f.Closed == nil {
+		// closed without a recorded transition, best we can do is the last update
+		d := i.Updated
+		f.Closed = &d
+	}
+
+	return f
+}
+
+// CalcTimeInStatus is the number of days an issue spent in each of its jira statuses, from created until closed or now
+func CalcTimeInStatus(f IssueFlow, events []cache.Event, now time.Time) map[string]float64 {
+	days := map[string]float64{}
+
+	end := now
+	if f.Closed != nil {
+		end = *f.Closed
+	}
+
+	status := f.Issue.Status
+	if len(events) > 0 {
+		status = events[0].From
+	}
+
+	at := f.Issue.Created
+	for _, e := range events {
+		if e.Date.After(end) {
+			break
+		}
+		if e.Date.After(at) {
+			days[status] += e.Date.Sub(at).Hours() / 24
+			at = e.Date
+		}
+		status = e.To
+	}
+
+	if end.After(at) && status != "Closed" {
+		days[status] += end.Sub(at).Hours() / 24
+	}
+
+	return days
+}
+
+// LabelPeriod is a span of time an issue had a label, To is nil while it still has it
+type LabelPeriod struct {
+	Label string
+	From  time.Time
+	To    *time.Time
+}
+
+// CalcLabelHistory works out when an issue had each label from its labels events, which jira records as the space
+// separated labels before and after each change. without events it has had its current labels since it was created
+func CalcLabelHistory(i cache.Issue, events []cache.Event) []LabelPeriod {
+	var periods []LabelPeriod
+	open := map[string]time.Time{}
+
+	labels := i.Labels
+	if len(events) > 0 {
+		labels = strings.Fields(events[0].From)
+	}
+	for _, l := range labels {
+		open[l] = i.Created
+	}
+
+	for _, e := range events {
+		now := map[string]bool{}
+		for _, l := range strings.Fields(e.To) {
+			now[l] = true
+			if _, ok := open[l]; !ok {
+				open[l] = e.Date
+			}
+		}
+
+		for l, since := range open {
+			if !now[l] {
+				removed := e.Date
+				periods = append(periods, LabelPeriod{Label: l, From: since, To: &removed})
+				delete(open, l)
+			}
+		}
+	}
+
+	for l, since := range open {
+		periods = append(periods, LabelPeriod{Label: l, From: since})
+	}
+
+	sort.Slice(periods, func(a, b int) bool {
+		if !periods[a].From.Equal(periods[b].From) {
+			return periods[a].From.Before(periods[b].From)
+		}
+		return periods[a].Label < periods[b].Label
+	})
+
+	return periods
+}
+
+type DailyLabelCounts struct {
+	Date   time.Time
+	Labels map[string]int
+}
+
+// CalcDailyOpenByLabel counts the issues open with each of the labels at the end of every day in the range, using the
+// labels they had on the day rather than their current ones
+func CalcDailyOpenByLabel(theCache *cache.Cache, flows []IssueFlow, labels []string, from, to time.Time) ([]DailyLabelCounts, error) {
+	want := map[string]bool{}
+	for _, l := range labels {
+		want[l] = true
+	}
+
+	var days []DailyLabelCounts
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, DailyLabelCounts{Date: day, Labels: map[string]int{}})
+	}
+
+	for _, f := range flows {
+		i := f.Issue
+		events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "labels")
+		if err != nil {
+			return nil, fmt.Errorf("getting label events for %s: %w", i.Key, err)
+		}
+
+		for _, p := range CalcLabelHistory(i, events) {
+			if !want[p.Label] {
+				continue
+			}
+
+			for n, d := range days {
+				end := d.Date.AddDate(0, 0, 1)
+				if !i.Created.Before(end) || (f.Closed != nil && f.Closed.Before(end)) {
+					continue
+				}
+				if !p.From.Before(end) || (p.To != nil && p.To.Before(end)) {
+					continue
+				}
+				days[n].Labels[p.Label]++
+			}
+		}
+	}
+
+	return days, nil
+}
+
+type WeeklyThroughput struct {
+	Week    time.Time // monday
+	Created float64
+	Closed  float64
+}
+
+func weekStart(t time.Time) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// CalcWeeklyThroughput counts issues created and closed per week within the range. created issues are weighted by their
+// current value as they are rarely estimated when created, closed ones by their value when closed
+func CalcWeeklyThroughput(flows []IssueFlow, w *Weight, from, to time.Time) []WeeklyThroughput {
+	weeks := map[time.Time]*WeeklyThroughput{}
+	var order []time.Time
+	for week := weekStart(from); !week.After(to); week = week.AddDate(0, 0, 7) {
+		weeks[week] = &WeeklyThroughput{Week: week}
+		order = append(order, week)
+	}
+
+	for _, f := range flows {
+		if week, ok := weeks[weekStart(f.Issue.Created)]; ok && !f.Issue.Created.Before(from) && !f.Issue.Created.After(to) {
+			week.Created += w.Current(f.Issue)
+		}
+		if f.Closed != nil {
+			if week, ok := weeks[weekStart(*f.Closed)]; ok && !f.Closed.Before(from) && !f.Closed.After(to) {
+				week.Closed += w.At(f.Issue, *f.Closed)
+			}
+		}
+	}
+
+	result := make([]WeeklyThroughput, 0, len(order))
+	for _, week := range order {
+		result = append(result, *weeks[week])
+	}
+
+	return result
+}
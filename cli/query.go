@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a filter over cached issues, terms that must all match written as field, operator and value:
+//
+//	type=Bug status="To Do",Blocked open=2026-03-01
+//	label~azure created>=2026-01 closed<2026-04-01 creator!=bot
+//
+// values with spaces can be quoted, or a word that isn't a term continues the previous value so status=To Do works.
+// a comma separated list matches any of its values, as does type in (Bug, Task) and type not in (Bug, Task) none
+// of them. fields are
+//
+//	key, status, group (the normalised status), type, resolution, label,   with = != ~ (contains) !~
+//	creator, assignee, reporter, priority, component, fix-version, affected-version, parent
+//	created, updated, closed                                                with = > >= < <= against a date
+//	open                                                                    with = a date the issue was open on
+//
+// assignee=Unassigned and priority=None match issues without one, as reports show them. dates are YYYY-MM-DD or
+// YYYY-MM for the first of the month. closed only matches closed issues
+type Query struct {
+	Terms []QueryTerm
+}
+
+type QueryTerm struct {
+	Field  string
+	Op     string
+	Values []string
+	Dates  []time.Time
+}
+
+var queryTextFields = map[string]bool{"key": true, "status": true, "group": true, "type": true, "resolution": true, "label": true, "creator": true, "assignee": true, "reporter": true, "priority": true, "component": true, "fix-version": true, "affected-version": true, "parent": true}
+var queryDateFields = map[string]bool{"created": true, "updated": true, "closed": true, "open": true}
+
+// the first operator in a term splits it, the longer one where two start at the same place so >= is not read as >
+var queryOps = []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"}
+
+func ParseQuery(s string) (*Query, error) {
+	words, err := splitQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []string
+	for n := 0; n < len(words); n++ {
+		w := words[n]
+		term, used, err := queryInTerm(words[n:])
+		if err != nil {
+			return nil, err
+		}
+		if used > 0 {
+			terms = append(terms, term)
+			n += used - 1
+			continue
+		}
+		if len(terms) > 0 && !hasQueryOp(w) {
+			terms[len(terms)-1] += " " + w
+			continue
+		}
+		terms = append(terms, w)
+	}
+
+	q := Query{}
+	for _, w := range terms {
+		t, err := parseQueryTerm(w)
+		if err != nil {
+			return nil, err
+		}
+		q.Terms = append(q.Terms, *t)
+	}
+
+	return &q, nil
+}
+
+// splitQuery splits on spaces outside of double quotes, removing the quotes
+func splitQuery(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	quoted, inWord := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case unicode.IsSpace(r) && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unterminated quote in query")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// queryInTerm rewrites field in (a, b) as field=a,b and field not in (a, b) as field!=a,b, returning the term and
+// how many words it used or 0 if the words don't start with one
+func queryInTerm(words []string) (string, int, error) {
+	if len(words) < 3 || hasQueryOp(words[0]) {
+		return "", 0, nil
+	}
+
+	op, start := "=", 1
+	if strings.EqualFold(words[1], "not") {
+		op, start = "!=", 2
+	}
+	if len(words) < start+2 || !strings.EqualFold(words[start], "in") || !strings.HasPrefix(words[start+1], "(") {
+		return "", 0, nil
+	}
+
+	for n := start + 1; n < len(words); n++ {
+		if strings.HasSuffix(words[n], ")") {
+			list := strings.Join(words[start+1:n+1], " ")
+			return words[0] + op + strings.TrimSpace(list[1:len(list)-1]), n + 1, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("query list for %s is missing a closing )", words[0])
+}
+
+func hasQueryOp(w string) bool {
+	for _, o := range queryOps {
+		if strings.Index(w, o) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseQueryTerm(w string) (*QueryTerm, error) {
+	at, op := -1, ""
+	for _, o := range queryOps {
+		if n := strings.Index(w, o); n > 0 && (at == -1 || n < at || (n == at && len(o) > len(op))) {
+			at, op = n, o
+		}
+	}
+	if at == -1 {
+		return nil, fmt.Errorf("query term %q is not field, operator and value", w)
+	}
+
+	t := QueryTerm{
+		Field: strings.ToLower(strings.TrimSpace(w[:at])),
+		Op:    op,
+	}
+	for _, v := range strings.Split(w[at+len(op):], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			t.Values = append(t.Values, v)
+		}
+	}
+	if len(t.Values) == 0 {
+		return nil, fmt.Errorf("query term %q has no value", w)
+	}
+
+	switch {
+	case queryTextFields[t.Field]:
+		if op != "=" && op != "!=" && op != "~" && op != "!~" {
+			return nil, fmt.Errorf("%s can't be compared with %s", t.Field, op)
+		}
+
+	case queryDateFields[t.Field]:
+		if op == "~" || op == "!~" || op == "!=" || (t.Field == "open" && op != "=") {
+			return nil, fmt.Errorf("%s can't be compared with %s", t.Field, op)
+		}
+		if len(t.Values) != 1 {
+			return nil, fmt.Errorf("%s takes a single date", t.Field)
+		}
+		d, err := parseQueryDate(t.Values[0])
+		if err != nil {
+			return nil, err
+		}
+		t.Dates = []time.Time{d}
+
+	default:
+		return nil, fmt.Errorf("unknown query field %s", t.Field)
+	}
+
+	return &t, nil
+}
+
+func parseQueryDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01"} {
+		if d, err := time.Parse(layout, s); err == nil {
+			return d, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("failed to parse date %s, expected YYYY-MM-DD or YYYY-MM", s)
+}
+
+// Match reports if an issue matches every term
+func (q Query) Match(model StatusModel, f IssueFlow) bool {
+	for _, t := range q.Terms {
+		if !t.match(model, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t QueryTerm) match(model StatusModel, f IssueFlow) bool {
+	i := f.Issue
+
+	switch t.Field {
+	case "key":
+		return t.matchText(i.Key)
+	case "status":
+		return t.matchText(i.Status)
+	case "group":
+		return t.matchText(model.Normalise(i.Status))
+	case "type":
+		return t.matchText(i.Type)
+	case "resolution":
+		return t.matchText(i.Resolution)
+	case "creator":
+		return t.matchText(i.Creator)
+	case "assignee":
+		return t.matchText(AssigneeName(i.Assignee))
+	case "reporter":
+		return t.matchText(i.Reporter)
+	case "priority":
+		return t.matchText(PriorityName(i.Priority))
+	case "component":
+		return t.matchText(i.Components...)
+	case "fix-version":
+		return t.matchText(i.FixVersions...)
+	case "affected-version":
+		return t.matchText(i.AffectedVersions...)
+	case "label":
+		return t.matchText(i.Labels...)
+	case "parent":
+		return t.matchText(i.Parent)
+
+	case "created":
+		return t.matchDate(i.Created)
+	case "updated":
+		return t.matchDate(i.Updated)
+	case "closed":
+		return f.Closed != nil && t.matchDate(*f.Closed)
+	case "open":
+		// open at some point during the day
+		day := t.Dates[0]
+		return i.Created.Before(day.AddDate(0, 0, 1)) && (f.Closed == nil || !f.Closed.Before(day))
+	}
+
+	return false
+}
+
+// matchText is true if any of the issue's values matches any of the term's, or for negated operators none do
+func (t QueryTerm) matchText(values ...string) bool {
+	negate := strings.HasPrefix(t.Op, "!")
+
+	for _, v := range values {
+		for _, want := range t.Values {
+			var hit bool
+			if strings.HasSuffix(t.Op, "~") {
+				hit = strings.Contains(strings.ToLower(v), strings.ToLower(want))
+			} else {
+				hit = strings.EqualFold(v, want)
+			}
+			if hit {
+				return !negate
+			}
+		}
+	}
+
+	return negate
+}
+
+// matchDate compares by day so created=2026-03-01 matches anything that day
+func (t QueryTerm) matchDate(d time.Time) bool {
+	day := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	want := t.Dates[0]
+
+	switch t.Op {
+	case "=":
+		return day.Equal(want)
+	case ">":
+		return day.After(want)
+	case ">=":
+		return !day.Before(want)
+	case "<":
+		return day.Before(want)
+	case "<=":
+		return !day.After(want)
+	}
+
+	return false
+}
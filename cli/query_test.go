@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		query    string
+		expected []QueryTerm
+	}{
+		{"", nil},
+		{"type=Bug", []QueryTerm{{Field: "type", Op: "=", Values: []string{"Bug"}}}},
+		{"TYPE=Bug", []QueryTerm{{Field: "type", Op: "=", Values: []string{"Bug"}}}},
+
+		// the longer operator where two start at the same place, otherwise the first one in the term
+		{"created>=2026-03-01", []QueryTerm{{Field: "created", Op: ">=", Values: []string{"2026-03-01"}, Dates: []time.Time{march}}}},
+		{"created<=2026-03", []QueryTerm{{Field: "created", Op: "<=", Values: []string{"2026-03"}, Dates: []time.Time{march}}}},
+		{"label!~az", []QueryTerm{{Field: "label", Op: "!~", Values: []string{"az"}}}},
+		{"label~a=b", []QueryTerm{{Field: "label", Op: "~", Values: []string{"a=b"}}}},
+		{"label=a!=b", []QueryTerm{{Field: "label", Op: "=", Values: []string{"a!=b"}}}},
+
+		// quoting, and words that aren't terms continuing the previous value
+		{`status="To Do",Blocked type=Bug`, []QueryTerm{{Field: "status", Op: "=", Values: []string{"To Do", "Blocked"}}, {Field: "type", Op: "=", Values: []string{"Bug"}}}},
+		{"status=To Do type=Bug", []QueryTerm{{Field: "status", Op: "=", Values: []string{"To Do"}}, {Field: "type", Op: "=", Values: []string{"Bug"}}}},
+		{"status = To Do", []QueryTerm{{Field: "status", Op: "=", Values: []string{"To Do"}}}},
+		{`assignee="Jane  Doe"`, []QueryTerm{{Field: "assignee", Op: "=", Values: []string{"Jane  Doe"}}}},
+		{`label="a=b"`, []QueryTerm{{Field: "label", Op: "=", Values: []string{"a=b"}}}},
+		{"label=a,,b,", []QueryTerm{{Field: "label", Op: "=", Values: []string{"a", "b"}}}},
+
+		// lists
+		{`type in (Bug, "Sub task")`, []QueryTerm{{Field: "type", Op: "=", Values: []string{"Bug", "Sub task"}}}},
+		{"type IN ( Bug , Task ) label=x", []QueryTerm{{Field: "type", Op: "=", Values: []string{"Bug", "Task"}}, {Field: "label", Op: "=", Values: []string{"x"}}}},
+		{"type not in (Bug)", []QueryTerm{{Field: "type", Op: "!=", Values: []string{"Bug"}}}},
+		{"status=Done type in (Bug)", []QueryTerm{{Field: "status", Op: "=", Values: []string{"Done"}}, {Field: "type", Op: "=", Values: []string{"Bug"}}}},
+		{"status in (To Do, In Progress)", []QueryTerm{{Field: "status", Op: "=", Values: []string{"To Do", "In Progress"}}}},
+	}
+
+	for _, tc := range cases {
+		q, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Errorf("parsing %q: %v", tc.query, err)
+			continue
+		}
+		if len(q.Terms) != len(tc.expected) {
+			t.Errorf("expected %q to have %d terms, got %+v", tc.query, len(tc.expected), q.Terms)
+			continue
+		}
+		for n, e := range tc.expected {
+			got := q.Terms[n]
+			if got.Field != e.Field || got.Op != e.Op || strings.Join(got.Values, "|") != strings.Join(e.Values, "|") || len(got.Dates) != len(e.Dates) || (len(e.Dates) > 0 && !got.Dates[0].Equal(e.Dates[0])) {
+				t.Errorf("%q term %d: expected %+v, got %+v", tc.query, n, e, got)
+			}
+		}
+	}
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{
+		"Bug",
+		"=Bug",
+		"type=",
+		"type=,",
+		`status="To Do`,
+		"colour=red",
+		"label>x",
+		"created~2026",
+		"created!=2026-01-01",
+		"open>2026-01-01",
+		"created=2026-01-01,2026-02-01",
+		"created=2026-13-01",
+		"closed=yesterday",
+		"type in (Bug",
+		"type in ()",
+		"type not in Bug",
+		"type=Bug colour in (red)",
+	} {
+		if q, err := ParseQuery(query); err == nil {
+			t.Errorf("expected %q to be invalid, got %+v", query, q.Terms)
+		}
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	t.Parallel()
+
+	at := func(day string) *time.Time {
+		d, err := time.Parse("2006-01-02 15:04", day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+
+	flows := []IssueFlow{
+		{Issue: cache.Issue{Key: "AB-1", Type: "Bug", Status: "To Do", Labels: []string{"azure", "network"}, Creator: "alice", Priority: "High", Created: *at("2026-02-10 10:00")}},
+		{Issue: cache.Issue{Key: "AB-2", Type: "Task", Status: "In Development", Creator: "bot", Assignee: "bob", Created: *at("2026-01-15 09:00")}, Closed: at("2026-03-01 15:00")},
+		{Issue: cache.Issue{Key: "AB-3", Type: "Story", Status: "Done", Resolution: "Fixed", Labels: []string{"aws"}, Creator: "alice", Assignee: "Jane Doe", Priority: "Low", Created: *at("2026-03-02 12:00")}, Closed: at("2026-03-20 08:00")},
+	}
+
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{"", "AB-1 AB-2 AB-3"},
+		{"type=Bug", "AB-1"},
+		{"type=bug", "AB-1"},
+		{"type=Bug,Task", "AB-1 AB-2"},
+		{"type in (Bug, Task)", "AB-1 AB-2"},
+		{"type not in (Bug, Task)", "AB-3"},
+		{"type!=Bug", "AB-2 AB-3"},
+		{"type!=Bug,Task", "AB-3"},
+		{"status=To Do", "AB-1"},
+		{`status="to do"`, "AB-1"},
+		{"group=In Progress", "AB-2"},
+		{"group=Other", "AB-3"},
+		{"resolution=Fixed", "AB-3"},
+
+		// any of an issue's values matching, and none of them for the negated operators including no values
+		{"label=network", "AB-1"},
+		{"label~az", "AB-1"},
+		{"label~A", "AB-1 AB-3"},
+		{"label!~az", "AB-2 AB-3"},
+		{"label!=aws", "AB-1 AB-2"},
+		{"key~ab-", "AB-1 AB-2 AB-3"},
+
+		// shown as in reports when there isn't one
+		{"assignee=Unassigned", "AB-1"},
+		{`assignee in ("jane doe", bob)`, "AB-2 AB-3"},
+		{"priority=None", "AB-2"},
+
+		// every term has to match
+		{"creator=alice type=Story", "AB-3"},
+		{"creator=alice type=Task", ""},
+
+		// dates by day, closed only matching closed issues
+		{"created=2026-02-10", "AB-1"},
+		{"created>=2026-02", "AB-1 AB-3"},
+		{"created<2026-02", "AB-2"},
+		{"created>2026-03-02", ""},
+		{"closed<2026-03-20", "AB-2"},
+		{"closed<=2026-03-20", "AB-2 AB-3"},
+		{"closed>=2026-01", "AB-2 AB-3"},
+		{"closed=2026-03", "AB-2"},
+
+		// open at some point during the day, including the day it was created or closed on
+		{"open=2026-03-01", "AB-1 AB-2"},
+		{"open=2026-03-02", "AB-1 AB-3"},
+		{"open=2026-01-14", ""},
+		{"open=2026-03-01 type!=Bug", "AB-2"},
+	}
+
+	for _, tc := range cases {
+		q, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Errorf("parsing %q: %v", tc.query, err)
+			continue
+		}
+
+		var matched []string
+		for _, f := range flows {
+			if q.Match(DefaultStatusModel, f) {
+				matched = append(matched, f.Issue.Key)
+			}
+		}
+		if got := strings.Join(matched, " "); got != tc.expected {
+			t.Errorf("expected %q to match %q, got %q", tc.query, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+// SprintPeriod is a span of time an issue was in a sprint, To is nil while it still is
+type SprintPeriod struct {
+	SprintID int
+	From     time.Time
+	To       *time.Time
+}
+
+// CalcSprintHistory works out when an issue was in each sprint from its Sprint events, which jira records as the comma
+// separated sprint ids before and after each change. without events it has been in its current sprints since it was
+// created. sprints not in known are skipped
+func CalcSprintHistory(i cache.Issue, current []int, events []cache.Event, known map[int]bool) []SprintPeriod {
+	ids := func(list string) []int {
+		var result []int
+		for _, s := range strings.Split(list, ",") {
+			if id, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && known[id] {
+				result = append(result, id)
+			}
+		}
+		return result
+	}
+
+	var periods []SprintPeriod
+	open := map[int]time.Time{}
+
+	sprints := current
+	if len(events) > 0 {
+		sprints = ids(events[0].FromID)
+	}
+	for _, id := range sprints {
+		open[id] = i.Created
+	}
+
+	for _, e := range events {
+		now := map[int]bool{}
+		for _, id := range ids(e.ToID) {
+			now[id] = true
+			if _, ok := open[id]; !ok {
+				open[id] = e.Date
+			}
+		}
+
+		for id, since := range open {
+			if !now[id] {
+				removed := e.Date
+				periods = append(periods, SprintPeriod{SprintID: id, From: since, To: &removed})
+				delete(open, id)
+			}
+		}
+	}
+
+	for id, since := range open {
+		periods = append(periods, SprintPeriod{SprintID: id, From: since})
+	}
+
+	sort.Slice(periods, func(a, b int) bool {
+		if !periods[a].From.Equal(periods[b].From) {
+			return periods[a].From.Before(periods[b].From)
+		}
+		return periods[a].SprintID < periods[b].SprintID
+	})
+
+	return periods
+}
+
+// SprintScope is which issues a sprint started with and how that changed while it ran
+type SprintScope struct {
+	Instance    string   `json:"instance"`
+	ID          int      `json:"id"`
+	BoardID     int      `json:"board_id"`
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	Goal        string   `json:"goal,omitempty"`
+	Start       string   `json:"start,omitempty"`
+	End         string   `json:"end,omitempty"`
+	Completed   string   `json:"completed,omitempty"`
+	Committed   []string `json:"committed"`    // in the sprint when it started, or now for future sprints
+	Added       []string `json:"added"`        // added after it started
+	Removed     []string `json:"removed"`      // removed before it completed
+	Done        []string `json:"done"`         // in the sprint at its end and done by then, so far while it is active
+	CarriedOver []string `json:"carried_over"` // in a completed sprint at its end but not done
+}
+
+// SprintEnd is when a sprint stopped changing, when it was completed, now while it is active
+func SprintEnd(s cache.Sprint, now time.Time) time.Time {
+	if s.CompleteDate.Valid {
+		return s.CompleteDate.Time
+	}
+	return now
+}
+
+// CalcSprintScopes replays every issue's sprint history against each sprint's dates to find what was committed to it
+// when it started, what was added and removed mid-sprint, and of what was left at its end what got done
+func CalcSprintScopes(theCache *cache.Cache, categories map[string]string, model StatusModel, sprints []cache.Sprint, issues []cache.Issue, now time.Time) ([]SprintScope, error) {
+	history, err := CalcSprintHistories(theCache, sprints, issues)
+	if err != nil {
+		return nil, err
+	}
+
+	statusEvents := map[string][]cache.Event{}
+	for _, i := range issues {
+		k := i.Instance + "/" + i.Key
+		if len(history[k]) == 0 {
+			continue
+		}
+
+		if statusEvents[k], err = theCache.GetIssueEventsForField(i.Instance, i.Key, "status"); err != nil {
+			return nil, fmt.Errorf("getting status events for %s: %w", i.Key, err)
+		}
+	}
+
+	scopes := make([]SprintScope, 0, len(sprints))
+	for _, s := range sprints {
+		scope := SprintScope{
+			Instance:    s.Instance,
+			ID:          s.ID,
+			BoardID:     s.BoardID,
+			Name:        s.Name,
+			State:       s.State,
+			Goal:        s.Goal,
+			Committed:   []string{},
+			Added:       []string{},
+			Removed:     []string{},
+			Done:        []string{},
+			CarriedOver: []string{},
+		}
+		if s.StartDate.Valid {
+			scope.Start = s.StartDate.Time.Format(APIDateFormat)
+		}
+		if s.EndDate.Valid {
+			scope.End = s.EndDate.Time.Format(APIDateFormat)
+		}
+		if s.CompleteDate.Valid {
+			scope.Completed = s.CompleteDate.Time.Format(APIDateFormat)
+		}
+
+		end := SprintEnd(s, now)
+		for _, i := range issues {
+			committed, added, removed, atEnd := false, false, false, false
+			for _, p := range history[i.Instance+"/"+i.Key] {
+				if p.SprintID != s.ID || i.Instance != s.Instance {
+					continue
+				}
+
+				if !s.StartDate.Valid {
+					committed = committed || p.To == nil
+					continue
+				}
+
+				start := s.StartDate.Time
+				if !p.From.After(start) {
+					committed = committed || p.To == nil || p.To.After(start)
+				} else if p.From.Before(end) {
+					added = true
+				}
+				if p.To != nil && p.To.After(start) && p.To.Before(end) {
+					removed = true
+				}
+				if !p.From.After(end) && (p.To == nil || !p.To.Before(end)) {
+					atEnd = true
+				}
+			}
+
+			if committed {
+				scope.Committed = append(scope.Committed, i.Key)
+			}
+			if added {
+				scope.Added = append(scope.Added, i.Key)
+			}
+			if removed {
+				scope.Removed = append(scope.Removed, i.Key)
+			}
+
+			if atEnd && s.StartDate.Valid {
+				status := StatusAt(i, statusEvents[i.Instance+"/"+i.Key], end)
+				if StatusCategory(categories, model, i.Instance, status) == "Done" {
+					scope.Done = append(scope.Done, i.Key)
+				} else if s.CompleteDate.Valid {
+					scope.CarriedOver = append(scope.CarriedOver, i.Key)
+				}
+			}
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
+// StatusAt is the status an issue had at a time from its status events, its current one without any
+func StatusAt(i cache.Issue, events []cache.Event, at time.Time) string {
+	status := i.Status
+	if len(events) > 0 {
+		status = events[0].From
+	}
+	for _, e := range events {
+		if e.Date.After(at) {
+			break
+		}
+		status = e.To
+	}
+	return status
+}
+
+// VelocitySprints is how many completed sprints of a board the rolling average velocity is over
+const VelocitySprints = 3
+
+// SprintVelocity is how many issues, or how much of a number field such as story points, a sprint committed to against
+// how many it completed, with the rolling average completed by its board's sprints
+type SprintVelocity struct {
+	Instance        string  `json:"instance"`
+	ID              int     `json:"id"`
+	BoardID         int     `json:"board_id"`
+	Name            string  `json:"name"`
+	State           string  `json:"state"`
+	Start           string  `json:"start,omitempty"`
+	End             string  `json:"end,omitempty"`
+	Committed       float64 `json:"committed"`
+	Added           float64 `json:"added"`
+	Removed         float64 `json:"removed"`
+	Completed       float64 `json:"completed"`
+	CarriedOver     float64 `json:"carried_over"`
+	AverageVelocity float64 `json:"average_velocity"` // over this and the previous completed sprints, the previous only while it is active
+}
+
+// CalcSprintVelocity weighs the started sprints' scopes, in order, averaging completed issues over the last
+// VelocitySprints completed sprints of each board. committed issues are weighted by their value when the sprint started
+// and the rest by their value when it ended. scopes are those of the sprints
+func CalcSprintVelocity(sprints []cache.Sprint, scopes []SprintScope, issues []cache.Issue, w *Weight, now time.Time) []SprintVelocity {
+	byKey := map[string]cache.Issue{}
+	for _, i := range issues {
+		byKey[i.Instance+"/"+i.Key] = i
+	}
+	sum := func(instance string, keys []string, at time.Time) float64 {
+		total := 0.0
+		for _, k := range keys {
+			total += w.At(byKey[instance+"/"+k], at)
+		}
+		return total
+	}
+
+	completed := map[string][]float64{}
+
+	result := []SprintVelocity{}
+	for n, s := range scopes {
+		if s.Start == "" {
+			continue
+		}
+		start, end := sprints[n].StartDate.Time, SprintEnd(sprints[n], now)
+
+		v := SprintVelocity{
+			Instance:    s.Instance,
+			ID:          s.ID,
+			BoardID:     s.BoardID,
+			Name:        s.Name,
+			State:       s.State,
+			Start:       s.Start,
+			End:         s.End,
+			Committed:   sum(s.Instance, s.Committed, start),
+			Added:       sum(s.Instance, s.Added, end),
+			Removed:     sum(s.Instance, s.Removed, end),
+			Completed:   sum(s.Instance, s.Done, end),
+			CarriedOver: sum(s.Instance, s.CarriedOver, end),
+		}
+
+		board := fmt.Sprintf("%s/%d", s.Instance, s.BoardID)
+		window := completed[board]
+		if s.Completed != "" {
+			window = append(window, v.Completed)
+			completed[board] = window
+		}
+		window = window[max(len(window)-VelocitySprints, 0):]
+		if len(window) > 0 {
+			total := 0.0
+			for _, c := range window {
+				total += c
+			}
+			v.AverageVelocity = total / float64(len(window))
+		}
+
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// CalcSprintVelocityInRange is the velocity of the sprints that ran at some point in the range, the average including
+// the completed sprints before it
+func CalcSprintVelocityInRange(theCache *cache.Cache, categories map[string]string, model StatusModel, w *Weight, issues []cache.Issue, from, to, now time.Time) ([]SprintVelocity, error) {
+	sprints, err := theCache.GetSprints()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, err := CalcSprintScopes(theCache, categories, model, sprints, issues, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ended := map[string]time.Time{}
+	for _, s := range sprints {
+		ended[fmt.Sprintf("%s/%d", s.Instance, s.ID)] = SprintEnd(s, now)
+	}
+
+	result := []SprintVelocity{}
+	for _, v := range CalcSprintVelocity(sprints, scopes, issues, w, now) {
+		start, _ := time.Parse(APIDateFormat, v.Start)
+		if start.After(to) || ended[fmt.Sprintf("%s/%d", v.Instance, v.ID)].Before(from) {
+			continue
+		}
+		result = append(result, v)
+	}
+
+	return result, nil
+}
+
+// CalcSprintHistories is the sprint history of every issue keyed by instance/key
+func CalcSprintHistories(theCache *cache.Cache, sprints []cache.Sprint, issues []cache.Issue) (map[string][]SprintPeriod, error) {
+	current, err := theCache.GetIssueSprints()
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]map[int]bool{}
+	for _, s := range sprints {
+		if known[s.Instance] == nil {
+			known[s.Instance] = map[int]bool{}
+		}
+		known[s.Instance][s.ID] = true
+	}
+
+	history := map[string][]SprintPeriod{}
+	for _, i := range issues {
+		k := i.Instance + "/" + i.Key
+		events, err := theCache.GetIssueEventsForField(i.Instance, i.Key, "Sprint")
+		if err != nil {
+			return nil, fmt.Errorf("getting sprint events for %s: %w", i.Key, err)
+		}
+		if len(events) == 0 && len(current[k]) == 0 {
+			continue
+		}
+
+		history[k] = CalcSprintHistory(i, current[k], events, known[i.Instance])
+	}
+
+	return history, nil
+}
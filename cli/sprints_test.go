@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+func TestCalcSprintHistoryByID(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	// both boards have a sprint with the same name, which has a comma in it
+	api := &models.BoardSprintScheme{ID: 11, Name: "Sprint 1, API"}
+	web := &models.BoardSprintScheme{ID: 21, Name: "Sprint 1, API"}
+	next := &models.BoardSprintScheme{ID: 12, Name: "Sprint 2"}
+
+	added, moved := created.Add(24*time.Hour), created.Add(72*time.Hour)
+	issue := jiratest.NewIssue("AB-1", "To Do", created).
+		SprintChange(added, nil, []*models.BoardSprintScheme{web}).
+		SprintChange(moved, []*models.BoardSprintScheme{web}, []*models.BoardSprintScheme{api, next})
+	if _, err := theCache.UpsertEventsFromIssue(cache.DefaultInstance, issue.IssueScheme); err != nil {
+		t.Fatalf("caching events: %v", err)
+	}
+
+	events, err := theCache.GetIssueEventsForField(cache.DefaultInstance, "AB-1", "Sprint")
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if len(events) != 2 || events[1].FromID != "21" || events[1].ToID != "11, 12" {
+		t.Fatalf("expected the sprint ids to be cached with the events, got %+v", events)
+	}
+
+	i := cache.Issue{Instance: cache.DefaultInstance, Key: "AB-1", Created: created}
+	periods := CalcSprintHistory(i, []int{11, 12}, events, map[int]bool{11: true, 12: true, 21: true})
+
+	expected := []SprintPeriod{
+		{SprintID: 21, From: added, To: &moved},
+		{SprintID: 11, From: moved},
+		{SprintID: 12, From: moved},
+	}
+	if len(periods) != len(expected) {
+		t.Fatalf("expected %d periods, got %+v", len(expected), periods)
+	}
+	for n, e := range expected {
+		p := periods[n]
+		if p.SprintID != e.SprintID || !p.From.Equal(e.From) || (p.To == nil) != (e.To == nil) || (p.To != nil && !p.To.Equal(*e.To)) {
+			t.Errorf("period %d: expected %+v, got %+v", n, e, p)
+		}
+	}
+}
+
+func TestUpsertIssueSprintsUpdatesSprints(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	board := []*models.BoardSprintScheme{{ID: 11, Name: "Sprint 1", State: "active", Goal: "ship it", StartDate: created}}
+	if err := theCache.UpsertBoardSprints(cache.DefaultInstance, 1, board); err != nil {
+		t.Fatalf("caching board sprints: %v", err)
+	}
+
+	sprint := func() cache.Sprint {
+		t.Helper()
+		sprints, err := theCache.GetSprints()
+		if err != nil || len(sprints) != 1 {
+			t.Fatalf("expected 1 sprint, got %v: %v", sprints, err)
+		}
+		return sprints[0]
+	}
+
+	// the field has the sprint renamed and closed since the board was fetched
+	completed := created.Add(14 * 24 * time.Hour)
+	closed := []*models.SprintDetailScheme{{ID: 11, Name: "Sprint 1 (API)", State: "closed", CompleteDate: completed.Format(time.RFC3339)}}
+	if err := theCache.UpsertIssueSprints(cache.DefaultInstance, "AB-1", closed); err != nil {
+		t.Fatalf("caching issue sprints: %v", err)
+	}
+	s := sprint()
+	if s.Name != "Sprint 1 (API)" || s.State != "closed" || !s.CompleteDate.Valid || !s.CompleteDate.Time.Equal(completed) {
+		t.Errorf("expected the sprint to be renamed and closed, got %+v", s)
+	}
+	if s.Goal != "ship it" || !s.StartDate.Valid || !s.StartDate.Time.Equal(created) {
+		t.Errorf("expected the goal and start date the field doesn't have to be kept, got %+v", s)
+	}
+
+	// an issue last fetched while the sprint was active doesn't reopen it
+	active := []*models.SprintDetailScheme{{ID: 11, Name: "Sprint 1", State: "active"}}
+	if err := theCache.UpsertIssueSprints(cache.DefaultInstance, "AB-2", active); err != nil {
+		t.Fatalf("caching issue sprints: %v", err)
+	}
+	if s := sprint(); s.Name != "Sprint 1 (API)" || s.State != "closed" {
+		t.Errorf("expected the closed sprint to be kept, got %+v", s)
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	c "github.com/gookit/color"
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/clog"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+)
+
+// max webhook body we'll accept, issues with large changelogs are still well under this
+const maxWebhookBody = 10 << 20
+
+// WebhookHandler applies jira issue created/updated/deleted webhooks to the cache. the instance is the one whose url
+// matches the issue, or can be set with an instance query parameter. issues are added to the instance's dataset when it
+// has only one, otherwise to the one set with a dataset query parameter
+func WebhookHandler(theCache *cache.Cache, secret string, instances []j.Instance, datasets []Dataset) http.HandlerFunc {
+	// webhooks carry custom fields by id, so find them once as fetch does
+	for n := range instances {
+		instances[n] = discoverCustomFields(instances[n])
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		if !j.VerifyWebhook(secret, body, r.Header.Get("X-Hub-Signature"), r.URL.Query().Get("secret")) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		e, err := j.ParseWebhook(body)
+		if err != nil {
+			clog.Log.Warnf("rejecting webhook: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		instance, err := webhookInstance(instances, e, r.URL.Query().Get("instance"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		in, err := webhookDatasets(datasets, *instance, r.URL.Query().Get("dataset"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := ApplyWebhook(theCache, *instance, in, e); err != nil {
+			clog.Log.Errorf("applying webhook %s for %s: %v", e.Event, e.Issue.Key, err)
+			http.Error(w, "failed to apply webhook", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func webhookInstance(instances []j.Instance, e *j.WebhookEvent, override string) (*j.Instance, error) {
+	if override != "" {
+		return GetInstance(instances, override)
+	}
+
+	if len(instances) == 1 {
+		return &instances[0], nil
+	}
+
+	self, err := url.Parse(e.Issue.Self)
+	if err != nil {
+		return nil, fmt.Errorf("parsing issue %s self url: %w", e.Issue.Key, err)
+	}
+	for n, i := range instances {
+		if u, err := url.Parse(i.URL); err == nil && strings.EqualFold(u.Host, self.Host) {
+			return &instances[n], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no configured instance matches %s, set the instance query parameter", self.Host)
+}
+
+// webhookDatasets are the datasets a webhook's issue belongs to. we can't run a jql against one issue, so it is the
+// instance's only dataset or the one asked for, with more than one the issue keeps what it is in until the next fetch
+func webhookDatasets(datasets []Dataset, instance j.Instance, override string) ([]string, error) {
+	var names []string
+	for _, d := range datasets {
+		if d.Instance != instance.Name {
+			continue
+		}
+		if override == d.Name {
+			return []string{d.Name}, nil
+		}
+		names = append(names, d.Name)
+	}
+
+	if override != "" {
+		return nil, fmt.Errorf("unknown dataset %q for instance %s", override, instance.Name)
+	}
+	if len(names) != 1 {
+		return nil, nil
+	}
+	return names, nil
+}
+
+// ApplyWebhook updates the cache using the same code paths as fetch
+func ApplyWebhook(theCache *cache.Cache, instance j.Instance, datasets []string, e *j.WebhookEvent) error {
+	i := e.Issue
+
+	if e.Event == j.WebhookIssueDeleted {
+		c.Printf("webhook <red>deleted</> %s/%s\n", instance.Name, i.Key)
+		return theCache.DeleteIssue(instance.Name, i.Key)
+	}
+
+	custom, err := instance.WebhookCustomFields(e)
+	if err != nil {
+		return err
+	}
+
+	count, _, err := upsertIssue(theCache, instance, datasets, i, custom)
+	if err != nil {
+		return err
+	}
+
+	c.Printf("webhook <cyan>%s</> %s/%s with <cyan>%d</> new events\n", e.Event, instance.Name, i.Key, count)
+	return nil
+}
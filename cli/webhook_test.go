@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+const webhookBody = `{
+	"timestamp": 1704272400000,
+	"webhookEvent": "jira:issue_updated",
+	"user": {"displayName": "Jira Test"},
+	"issue": {
+		"id": "10001",
+		"key": "AB-2",
+		"self": "https://example.atlassian.net/rest/api/2/issue/10001",
+		"fields": {
+			"summary": "summary of AB-2",
+			"status": {"name": "In Progress"},
+			"issuetype": {"name": "Story"},
+			"creator": {"displayName": "Jira Test"},
+			"created": "2024-01-02T09:00:00.000+0000",
+			"updated": "2024-01-03T09:00:00.000+0000",
+			"customfield_10014": "AB-1",
+			"customfield_10016": 3
+		}
+	},
+	"changelog": {"id": "1", "items": [{"field": "status", "fromString": "To Do", "toString": "In Progress"}]}
+}`
+
+func TestApplyWebhook(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	inst := j.NewInstance("https://example.atlassian.net", "", "")
+	inst.EpicLinkField = "customfield_10014"
+	inst.NumberFields = []j.NumberField{{Name: j.StoryPoints, ID: "customfield_10016"}}
+
+	e, err := j.ParseWebhook([]byte(webhookBody))
+	if err != nil {
+		t.Fatalf("parsing webhook: %v", err)
+	}
+	if err := ApplyWebhook(theCache, inst, []string{"team"}, e); err != nil {
+		t.Fatalf("applying webhook: %v", err)
+	}
+
+	i, err := theCache.GetIssue(inst.Name, "AB-2")
+	if err != nil {
+		t.Fatalf("reading AB-2: %v", err)
+	}
+	if i.Parent != "AB-1" || i.Status != "In Progress" {
+		t.Errorf("expected AB-2 to be In Progress with its epic link as parent, got %+v", i)
+	}
+
+	numbers, err := theCache.GetIssueNumbers(inst.Name, "AB-2")
+	if err != nil {
+		t.Fatalf("reading AB-2 numbers: %v", err)
+	}
+	if numbers[j.StoryPoints] != 3 {
+		t.Errorf("expected AB-2 to have 3 story points, got %v", numbers)
+	}
+
+	datasets, err := theCache.GetDatasets()
+	if err != nil {
+		t.Fatalf("reading datasets: %v", err)
+	}
+	if len(datasets) != 1 || datasets[0] != "team" {
+		t.Errorf("expected AB-2 to be in the team dataset, got %v", datasets)
+	}
+
+	events, err := theCache.GetIssueEvents(inst.Name, "AB-2")
+	if err != nil {
+		t.Fatalf("reading AB-2 events: %v", err)
+	}
+	if len(events) != 1 || events[0].To != "In Progress" {
+		t.Errorf("expected the webhook's status change, got %+v", events)
+	}
+}
+
+func TestWebhookThenFetchIsOneEvent(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+
+	// the change happened 12 hours before the webhook was delivered
+	changed := created.Add(12 * time.Hour)
+	s.AddIssues(jiratest.NewIssue("AB-2", "To Do", created).Transition(changed, "To Do", "In Progress"))
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+	theCache, err := cache.Open(path)
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	e, err := j.ParseWebhook([]byte(strings.Replace(webhookBody, `"id": "1"`, `"id": "AB-2-1"`, 1)))
+	if err != nil {
+		t.Fatalf("parsing webhook: %v", err)
+	}
+	if err := ApplyWebhook(theCache, s.Instance(), []string{cache.DefaultDataset}, e); err != nil {
+		t.Fatalf("applying webhook: %v", err)
+	}
+
+	f := FlagData{Url: s.URL, User: jiratest.User, Token: jiratest.Token, JQL: "project = AB", CachePath: path}
+	if err := RunFetch(context.Background(), f); err != nil {
+		t.Fatalf("fetching: %v", err)
+	}
+
+	check := func(after string) {
+		t.Helper()
+
+		events, err := theCache.GetIssueEvents(cache.DefaultInstance, "AB-2")
+		if err != nil {
+			t.Fatalf("reading AB-2 events: %v", err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("after %s expected the webhook and fetch of the same change to be one event, got %+v", after, events)
+		}
+		if e := events[0]; e.HistoryID != "AB-2-1" || !e.Date.Equal(changed) {
+			t.Errorf("after %s expected the event to have the history's id and the date it changed, got %+v", after, e)
+		}
+	}
+	check("the fetch")
+
+	// the webhook delivered again doesn't move the change to when it was delivered
+	if err := ApplyWebhook(theCache, s.Instance(), []string{cache.DefaultDataset}, e); err != nil {
+		t.Fatalf("applying webhook: %v", err)
+	}
+	check("the webhook again")
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+)
+
+// WeightCount weights every issue the same, so metrics count issues
+const WeightCount = "count"
+
+// Weight is how much each issue counts for in the daily, throughput, sprint and burn-up metrics, 1 when counting issues
+// otherwise the value of a number field such as story points, 0 while an issue doesn't have it set
+type Weight struct {
+	Name    string                   // WeightCount or the number field
+	values  map[string]float64       // current values by instance/key
+	history map[string][]cache.Event // changes to the field by instance/key, in order
+}
+
+var CountWeight = &Weight{Name: WeightCount}
+
+// NewWeight weights metrics by the named number field, replaying its changes from the events, or counts issues
+func NewWeight(theCache *cache.Cache, name string) (*Weight, error) {
+	if name == "" || name == WeightCount {
+		return CountWeight, nil
+	}
+
+	fields, err := theCache.GetNumberFields()
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, f := range fields {
+		found = found || f.Name == name
+	}
+	if !found {
+		return nil, fmt.Errorf("invalid weight %q, expected %s or a number field fetched with --number-field %s=customfield_XXXXX", name, WeightCount, name)
+	}
+
+	w := Weight{Name: name, history: map[string][]cache.Event{}}
+	if w.values, err = theCache.GetNumbers(name); err != nil {
+		return nil, err
+	}
+
+	events, err := theCache.GetNumberEvents(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		k := e.Instance + "/" + e.Key
+		w.history[k] = append(w.history[k], e)
+	}
+
+	return &w, nil
+}
+
+// Counts is true when issues are counted rather than weighted by a field
+func (w *Weight) Counts() bool {
+	return w.Name == WeightCount
+}
+
+// Unit is what weighted metrics are in, for axis names
+func (w *Weight) Unit() string {
+	if w.Counts() {
+		return "# Issues"
+	}
+	return strings.ReplaceAll(w.Name, "_", " ")
+}
+
+// Current is an issue's weight now
+func (w *Weight) Current(i cache.Issue) float64 {
+	if w.Counts() {
+		return 1
+	}
+	return w.values[i.Instance+"/"+i.Key]
+}
+
+// At is an issue's weight at a time, from the value it had before its first change to the field and after each change
+// since, or its current value when it has never changed
+func (w *Weight) At(i cache.Issue, at time.Time) float64 {
+	if w.Counts() {
+		return 1
+	}
+
+	events := w.history[i.Instance+"/"+i.Key]
+	if len(events) == 0 {
+		return w.Current(i)
+	}
+
+	value := events[0].From
+	for _, e := range events {
+		if e.Date.After(at) {
+			break
+		}
+		value = e.To
+	}
+
+	v, _ := strconv.ParseFloat(strings.TrimSpace(value), 64) // unset is empty, so 0
+	return v
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// when an issue moves project jira gives it a new key and records the old one in the changelog under the "Key" field
+const CreateIssueAliasesTableSQL = `
+	CREATE TABLE "issue_aliases" (
+	    "alias" CHAR(16) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "date" DATE NOT NULL,
+	    PRIMARY KEY (alias)
+	)
+`
+
+const MigrateIssueAliasesInstanceSQL = `
+	CREATE TABLE "issue_aliases_instance" (
+	    "instance" CHAR(64) NOT NULL,
+	    "alias" CHAR(16) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "date" DATE NOT NULL,
+	    PRIMARY KEY (instance, alias)
+	);
+	INSERT INTO issue_aliases_instance (instance, alias, key, date)
+	SELECT '` + DefaultInstance + `', alias, key, date FROM issue_aliases;
+	DROP TABLE issue_aliases;
+	ALTER TABLE issue_aliases_instance RENAME TO issue_aliases;
+`
+
+type IssueAlias struct {
+	Alias string
+	Key   string
+	Date  time.Time
+}
+
+// UpsertAliasesFromIssue records every previous key found in the changelog as an alias of the current key and merges
+// anything cached under the old keys into it, returning the aliases found
+func (cache Cache) UpsertAliasesFromIssue(instance string, issue *models.IssueScheme) ([]IssueAlias, error) {
+	if issue.Changelog == nil {
+		return nil, nil
+	}
+
+	var aliases []IssueAlias
+	for _, change := range issue.Changelog.Histories {
+		for _, item := range change.Items {
+			if item.Field != "Key" || item.FromString == "" || item.FromString == issue.Key {
+				continue
+			}
+
+			date, err := time.Parse("2006-01-02T15:04:05.000-0700", change.Created)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse key change date %s: %w", change.Created, err)
+			}
+
+			aliases = append(aliases, IssueAlias{Alias: item.FromString, Key: issue.Key, Date: date})
+		}
+	}
+
+	for _, a := range aliases {
+		_, err := cache.DB.Exec(`
+			INSERT OR REPLACE INTO issue_aliases (instance, alias, key, date)
+			VALUES (?, ?, ?, ?)
+		`, instance, a.Alias, a.Key, a.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert alias %s for issue %s: %w", a.Alias, a.Key, err)
+		}
+
+		if err := cache.MergeIssueKey(instance, a.Alias, a.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	return aliases, nil
+}
+
+// MergeIssueKey moves events cached under an old key to the new key and removes the old issue so it isn't counted twice
+func (cache Cache) MergeIssueKey(instance, from, to string) error {
+	// the changelog moves with the issue so most events will already exist under the new key
+	if _, err := cache.DB.Exec(`UPDATE OR IGNORE events SET key = ? WHERE instance = ? AND key = ?`, to, instance, from); err != nil {
+		return fmt.Errorf("failed to move events from %s to %s: %w", from, to, err)
+	}
+
+	if _, err := cache.DB.Exec(`UPDATE OR IGNORE dataset_issues SET key = ? WHERE instance = ? AND key = ?`, to, instance, from); err != nil {
+		return fmt.Errorf("failed to move dataset links from %s to %s: %w", from, to, err)
+	}
+
+	return cache.DeleteIssue(instance, from)
+}
+
+// ResolveKey returns the current key for an issue, following any aliases from project moves
+func (cache Cache) ResolveKey(instance, key string) (string, error) {
+	var current string
+
+	err := cache.DB.QueryRow(`SELECT key FROM issue_aliases WHERE instance = ? AND alias = ?`, instance, key).Scan(&current)
+	if err == sql.ErrNoRows { //nolint:errorlint // sql returns this unwrapped
+		return key, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve alias %s: %w", key, err)
+	}
+
+	return current, nil
+}
+
+func (cache Cache) GetAliasesForIssue(instance, key string) ([]IssueAlias, error) {
+	rows, err := cache.DB.Query(`SELECT alias, key, date FROM issue_aliases WHERE instance = ? AND key = ? ORDER BY date`, instance, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases for issue %s: %w", key, err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var aliases []IssueAlias
+	for rows.Next() {
+		a := IssueAlias{}
+		if err = rows.Scan(&a.Alias, &a.Key, &a.Date); err != nil {
+			return nil, fmt.Errorf("failed to scan aliases for issue %s: %w", key, err)
+		}
+		aliases = append(aliases, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating aliases for issue %s: %w", key, err)
+	}
+
+	return aliases, nil
+}
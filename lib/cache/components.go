@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// components and versions are many to many with issues so each gets a row per issue, replaced whenever the issue is
+const CreateIssueComponentsTableSQL = `
+	CREATE TABLE "issue_components" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "component" CHAR(64) NOT NULL,
+	    PRIMARY KEY (instance, key, component)
+	)
+`
+
+// kind is fix for fix versions and affected for affected versions
+const CreateIssueVersionsTableSQL = `
+	CREATE TABLE "issue_versions" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "kind" CHAR(16) NOT NULL,
+	    "version" CHAR(64) NOT NULL,
+	    PRIMARY KEY (instance, key, kind, version)
+	)
+`
+
+const (
+	VersionKindFix      = "fix"
+	VersionKindAffected = "affected"
+)
+
+// upsertIssueComponents replaces an issue's components and versions with those from jira
+func (cache Cache) upsertIssueComponents(instance string, issue *models.IssueScheme) error {
+	if _, err := cache.DB.Exec(`DELETE FROM issue_components WHERE instance = ? AND key = ?`, instance, issue.Key); err != nil {
+		return fmt.Errorf("failed to clear components for issue %s: %w", issue.Key, err)
+	}
+	if _, err := cache.DB.Exec(`DELETE FROM issue_versions WHERE instance = ? AND key = ?`, instance, issue.Key); err != nil {
+		return fmt.Errorf("failed to clear versions for issue %s: %w", issue.Key, err)
+	}
+
+	for _, comp := range issue.Fields.Components {
+		if comp == nil || comp.Name == "" {
+			continue
+		}
+		_, err := cache.DB.Exec(`INSERT OR IGNORE INTO issue_components (instance, key, component) VALUES (?, ?, ?)`, instance, issue.Key, comp.Name)
+		if err != nil {
+			return fmt.Errorf("failed to insert component %s for issue %s: %w", comp.Name, issue.Key, err)
+		}
+	}
+
+	for kind, versions := range map[string][]*models.VersionScheme{VersionKindFix: issue.Fields.FixVersions, VersionKindAffected: issue.Fields.Versions} {
+		for _, v := range versions {
+			if v == nil || v.Name == "" {
+				continue
+			}
+			_, err := cache.DB.Exec(`INSERT OR IGNORE INTO issue_versions (instance, key, kind, version) VALUES (?, ?, ?, ?)`, instance, issue.Key, kind, v.Name)
+			if err != nil {
+				return fmt.Errorf("failed to insert %s version %s for issue %s: %w", kind, v.Name, issue.Key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// issueLists are an issue's labels, components and versions, read for many issues at once rather than a query per issue
+type issueLists struct {
+	Labels           []string
+	Components       []string
+	FixVersions      []string
+	AffectedVersions []string
+}
+
+// getIssueLists reads the lists of the issues matching a where clause on instance and key
+func (cache Cache) getIssueLists(where string) (map[string]*issueLists, error) {
+	lists := map[string]*issueLists{}
+	get := func(instance, key string) *issueLists {
+		k := instance + "/" + key
+		if lists[k] == nil {
+			lists[k] = &issueLists{}
+		}
+		return lists[k]
+	}
+
+	rows, err := cache.DB.Query(fmt.Sprintf(`
+		SELECT instance, key, 'label', label FROM issue_labels WHERE %[1]s
+		UNION ALL
+		SELECT instance, key, 'component', component FROM issue_components WHERE %[1]s
+		UNION ALL
+		SELECT instance, key, kind, version FROM issue_versions WHERE %[1]s
+		ORDER BY 1, 2, 3, 4
+	`, where))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue labels, components and versions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		var instance, key, kind, name string
+		if err = rows.Scan(&instance, &key, &kind, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan issue labels, components and versions: %w", err)
+		}
+
+		l := get(instance, key)
+		switch kind {
+		case "label":
+			l.Labels = append(l.Labels, name)
+		case "component":
+			l.Components = append(l.Components, name)
+		case VersionKindFix:
+			l.FixVersions = append(l.FixVersions, name)
+		case VersionKindAffected:
+			l.AffectedVersions = append(l.AffectedVersions, name)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating issue labels, components and versions: %w", err)
+	}
+
+	return lists, nil
+}
+
+// applyIssueLists reads and sets the lists of just these issues
+func (cache Cache) applyIssueLists(issues []*Issue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(issues))
+	for _, i := range issues {
+		keys = append(keys, fmt.Sprintf("(%s, %s)", sqlString(i.Instance), sqlString(i.Key)))
+	}
+
+	lists, err := cache.getIssueLists(fmt.Sprintf("(instance, key) IN (VALUES %s)", strings.Join(keys, ", ")))
+	if err != nil {
+		return err
+	}
+
+	for _, i := range issues {
+		lists[i.Instance+"/"+i.Key].apply(i)
+	}
+
+	return nil
+}
+
+func (l *issueLists) apply(i *Issue) {
+	if l == nil {
+		return
+	}
+	if l.Labels != nil {
+		i.Labels = l.Labels
+	}
+	i.Components = l.Components
+	i.FixVersions = l.FixVersions
+	i.AffectedVersions = l.AffectedVersions
+}
+
+// listClause limits issues to those with any of the cache's labels, components and fix versions
+func (cache Cache) listClause() []string {
+	var clauses []string
+
+	if len(cache.Labels) > 0 {
+		clauses = append(clauses, fmt.Sprintf("(instance, key) IN (SELECT instance, key FROM issue_labels WHERE label IN (%s))", sqlStrings(cache.Labels)))
+	}
+
+	if len(cache.Components) > 0 {
+		clauses = append(clauses, fmt.Sprintf("(instance, key) IN (SELECT instance, key FROM issue_components WHERE component IN (%s))", sqlStrings(cache.Components)))
+	}
+
+	if len(cache.FixVersions) > 0 {
+		clauses = append(clauses, fmt.Sprintf("(instance, key) IN (SELECT instance, key FROM issue_versions WHERE kind = '%s' AND version IN (%s))", VersionKindFix, sqlStrings(cache.FixVersions)))
+	}
+
+	return clauses
+}
+
+func sqlStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for n, v := range values {
+		quoted[n] = sqlString(v)
+	}
+	return strings.Join(quoted, ", ")
+}
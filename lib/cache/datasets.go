@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultDataset is the dataset used when no datasets are configured, and the one issues cached before datasets existed belong to
+const DefaultDataset = "default"
+
+// an issue can be returned by more than one dataset's jql, each link is tombstoned separately
+const CreateDatasetIssuesTableSQL = `
+	CREATE TABLE "dataset_issues" (
+	    "dataset" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "missing" DATE,
+	    PRIMARY KEY (dataset, key)
+	)
+`
+
+const MigrateDatasetIssuesInstanceSQL = `
+	CREATE TABLE "dataset_issues_instance" (
+	    "dataset" CHAR(64) NOT NULL,
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "missing" DATE,
+	    PRIMARY KEY (dataset, instance, key)
+	);
+	INSERT INTO dataset_issues_instance (dataset, instance, key, missing)
+	SELECT dataset, '` + DefaultInstance + `', key, missing FROM dataset_issues;
+	DROP TABLE dataset_issues;
+	ALTER TABLE dataset_issues_instance RENAME TO dataset_issues;
+`
+
+const MigrateIssuesToDefaultDatasetSQL = `
+	INSERT OR IGNORE INTO dataset_issues (dataset, key, missing)
+	SELECT '` + DefaultDataset + `', key, missing FROM issues
+`
+
+func (cache Cache) LinkIssueToDataset(dataset, instance, key string) error {
+	_, err := cache.DB.Exec(`
+		INSERT OR REPLACE INTO dataset_issues (dataset, instance, key, missing)
+		VALUES (?, ?, ?, NULL)
+	`, dataset, instance, key)
+	if err != nil {
+		return fmt.Errorf("failed to link issue %s to dataset %s: %w", key, dataset, err)
+	}
+
+	return nil
+}
+
+func (cache Cache) GetDatasets() ([]string, error) {
+	rows, err := cache.DB.Query(`SELECT DISTINCT dataset FROM dataset_issues ORDER BY dataset`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query datasets: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var datasets []string
+	for rows.Next() {
+		var d string
+		if err = rows.Scan(&d); err != nil {
+			return nil, fmt.Errorf("failed to scan datasets: %w", err)
+		}
+		datasets = append(datasets, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating datasets: %w", err)
+	}
+
+	return datasets, nil
+}
+
+// TombstoneDatasetIssuesNotIn marks every issue linked to the dataset but not in seen as missing from it. issues missing
+// from every dataset they belong to are tombstoned and returned
+func (cache Cache) TombstoneDatasetIssuesNotIn(dataset, instance string, seen map[string]bool, at time.Time) ([]Issue, error) {
+	rows, err := cache.DB.Query(`SELECT key FROM dataset_issues WHERE dataset = ? AND instance = ? AND missing IS NULL`, dataset, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issues for dataset %s: %w", dataset, err)
+	}
+
+	var unseen []string
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			rows.Close() //nolint:errcheck,gosec
+			return nil, fmt.Errorf("failed to scan issues for dataset %s: %w", dataset, err)
+		}
+		if !seen[key] {
+			unseen = append(unseen, key)
+		}
+	}
+	rows.Close() //nolint:errcheck,gosec
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating issues for dataset %s: %w", dataset, err)
+	}
+
+	var tombstoned []Issue
+	for _, key := range unseen {
+		if _, err := cache.DB.Exec(`UPDATE dataset_issues SET missing = ? WHERE dataset = ? AND instance = ? AND key = ?`, at, dataset, instance, key); err != nil {
+			return nil, fmt.Errorf("failed to tombstone issue %s in dataset %s: %w", key, dataset, err)
+		}
+
+		// still returned by another dataset
+		var remaining int
+		if err := cache.DB.QueryRow(`SELECT COUNT(*) FROM dataset_issues WHERE instance = ? AND key = ? AND missing IS NULL`, instance, key).Scan(&remaining); err != nil {
+			return nil, fmt.Errorf("failed to count datasets for issue %s: %w", key, err)
+		}
+		if remaining > 0 {
+			continue
+		}
+
+		if _, err := cache.DB.Exec(`UPDATE issues SET missing = ? WHERE instance = ? AND key = ? AND missing IS NULL`, at, instance, key); err != nil {
+			return nil, fmt.Errorf("failed to tombstone issue %s: %w", key, err)
+		}
+
+		i, err := cache.GetIssue(instance, key)
+		if err != nil {
+			return nil, err
+		}
+		if i != nil {
+			i.Missing = sql.NullTime{Time: at, Valid: true}
+			tombstoned = append(tombstoned, *i)
+		}
+	}
+
+	return tombstoned, nil
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -11,8 +11,25 @@ import (
 type Cache struct {
 	Path string
 	DB   *sql.DB
+
+	// include issues tombstoned as missing from the jql when listing issues
+	IncludeMissing bool
+
+	// limit listed issues to those returned by this dataset's jql, all issues when empty
+	Dataset string
+
+	// limit listed issues to this jira instance, all instances when empty
+	Instance string
+
+	// limit listed issues to those with any of these labels, components or fix versions, all issues when empty
+	Labels      []string
+	Components  []string
+	FixVersions []string
 }
 
+// DefaultInstance is the jira instance configured by --url, and the one issues cached before instances existed belong to
+const DefaultInstance = "default"
+
 func Open(path string) (*Cache, error) {
 	// exists?
 	if _, err := os.Stat(path); err == nil {
@@ -22,7 +39,11 @@ func Open(path string) (*Cache, error) {
 			return nil, fmt.Errorf("failed to open db %s: %w", path, err)
 		}
 
-		return &Cache{path, db}, nil
+		if err = migrate(db); err != nil {
+			return nil, fmt.Errorf("failed to migrate db %s: %w", path, err)
+		}
+
+		return &Cache{Path: path, DB: db}, nil
 	}
 
 	// create file
@@ -45,5 +66,60 @@ func Open(path string) (*Cache, error) {
 		return nil, fmt.Errorf("failed to create events table %s: %w", path, err)
 	}
 
-	return &Cache{path, db}, nil
+	if err = migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to create tables %s: %w", path, err)
+	}
+
+	return &Cache{Path: path, DB: db}, nil
+}
+
+// schema changes made after the original tables, applied in order and tracked with sqlite's user_version so existing
+// caches are upgraded in place. only ever append to this list
+var migrations = []string{
+	CreateSyncsTableSQL,
+	DedupeEventsSQL,
+	CreateEventsUniqueIndexSQL,
+	AddIssuesMissingColumnSQL,
+	CreateIssueAliasesTableSQL,
+	CreateDatasetIssuesTableSQL,
+	MigrateIssuesToDefaultDatasetSQL,
+	MigrateIssuesInstanceSQL,
+	MigrateEventsInstanceSQL,
+	MigrateIssueAliasesInstanceSQL,
+	MigrateDatasetIssuesInstanceSQL,
+	AddIssuesPeopleColumnsSQL,
+	CreateIssueComponentsTableSQL,
+	CreateIssueVersionsTableSQL,
+	CreateVersionsTableSQL,
+	CreateIssueLabelsTableSQL,
+	MigrateIssueLabelsSQL,
+	AddIssuesParentColumnSQL,
+	CreateStatusesTableSQL,
+	CreateIssueLinksTableSQL,
+	CreateBoardsTableSQL,
+	CreateSprintsTableSQL,
+	CreateIssueSprintsTableSQL,
+	CreateNumberFieldsTableSQL,
+	CreateIssueNumbersTableSQL,
+	AddEventsIDColumnsSQL,
+	AddEventsHistoryIDSQL,
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := db.Exec(migrations[i]); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			return fmt.Errorf("failed to set schema version %d: %w", i+1, err)
+		}
+	}
+
+	return nil
 }
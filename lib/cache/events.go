@@ -8,7 +8,7 @@ import (
 	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 )
 
-var EventColumns = []string{"id", "key", "author", "date", "field", "[from]", "[to]"}
+var EventColumns = []string{"id", "instance", "key", "author", "date", "field", "[from]", "[to]", "from_id", "to_id", "history_id"}
 
 func EventColumnsString() string {
 	return strings.Join(EventColumns, ", ")
@@ -30,17 +30,56 @@ const CreateEventsTableSQL = `
 	)
 `
 
+// older versions inserted every changelog entry again on each fetch, so remove those before making events unique
+const DedupeEventsSQL = `
+	DELETE FROM events WHERE id NOT IN (
+		SELECT MIN(id) FROM events GROUP BY key, author, date, field, [from], [to]
+	)
+`
+
+const CreateEventsUniqueIndexSQL = `
+	CREATE UNIQUE INDEX IF NOT EXISTS "events_unique" ON "events" (key, author, date, field, [from], [to])
+`
+
+// events predate instances so the column is added at the end and the unique index rebuilt to include it
+const MigrateEventsInstanceSQL = `
+	ALTER TABLE "events" ADD COLUMN "instance" CHAR(64) NOT NULL DEFAULT '` + DefaultInstance + `';
+	DROP INDEX IF EXISTS "events_unique";
+	CREATE UNIQUE INDEX "events_unique" ON "events" (instance, key, author, date, field, [from], [to]);
+`
+
+// the ids jira records alongside the display strings of a change, such as the sprint ids of a Sprint change whose names
+// are neither unique nor free of commas. events cached before them get theirs on the next fetch
+const AddEventsIDColumnsSQL = `
+	ALTER TABLE "events" ADD COLUMN "from_id" VARCHAR(256) NOT NULL DEFAULT '';
+	ALTER TABLE "events" ADD COLUMN "to_id" VARCHAR(256) NOT NULL DEFAULT '';
+`
+
+// the id of the changelog history an event came from, so the same change arriving from a webhook and a fetch with
+// different dates is one event. events keep their own unique index for those cached before it, which get theirs on the
+// next fetch
+const AddEventsHistoryIDSQL = `
+	ALTER TABLE "events" ADD COLUMN "history_id" VARCHAR(32) NOT NULL DEFAULT '';
+	DROP INDEX IF EXISTS "events_unique";
+	CREATE UNIQUE INDEX "events_unique" ON "events" (instance, key, history_id, author, date, field, [from], [to]);
+	CREATE UNIQUE INDEX "events_history" ON "events" (instance, key, history_id, field) WHERE history_id <> '';
+`
+
 type Event struct {
-	ID     int
-	Key    string
-	Author string
-	Date   time.Time
-	Field  string
-	From   string
-	To     string
+	ID        int
+	Instance  string
+	Key       string
+	Author    string
+	Date      time.Time
+	Field     string
+	From      string
+	To        string
+	FromID    string // empty when the field has no ids
+	ToID      string
+	HistoryID string // empty for events cached before history ids
 }
 
-func (cache Cache) UpsertEventsFromIssue(issue *models.IssueScheme) (*int, error) {
+func (cache Cache) UpsertEventsFromIssue(instance string, issue *models.IssueScheme) (*int, error) {
 	count := 0
 	if issue.Changelog == nil {
 		return &count, nil
@@ -58,34 +97,83 @@ func (cache Cache) UpsertEventsFromIssue(issue *models.IssueScheme) (*int, error
 		}
 
 		for _, item := range change.Items {
+			// an event cached before history ids becomes this history's, unless a webhook already cached it as one
+			if change.ID != "" {
+				if err := cache.claimEvent(instance, issue.Key, change.ID, author, date, item); err != nil {
+					return nil, fmt.Errorf("failed to claim issue %s changelog: %w", issue.Key, err)
+				}
+			}
+
+			// a history we already have keeps the earliest date it arrived with, which is when it happened rather than
+			// when a webhook was delivered. other events we already have only change when they are missing their ids
 			stmt, err := cache.DB.Prepare(`
-				INSERT INTO events (key, author, date, field, [from], [to])
-				VALUES (?, ?, ?, ?, ?, ?)
+				INSERT INTO events (instance, key, author, date, field, [from], [to], from_id, to_id, history_id)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (instance, key, history_id, field) WHERE history_id <> '' DO UPDATE SET
+					author = excluded.author,
+					date = excluded.date,
+					[from] = excluded.[from],
+					[to] = excluded.[to],
+					from_id = excluded.from_id,
+					to_id = excluded.to_id
+				WHERE julianday(excluded.date) < julianday(date)
+				ON CONFLICT (instance, key, history_id, author, date, field, [from], [to]) DO UPDATE SET
+					from_id = excluded.from_id,
+					to_id = excluded.to_id
+				WHERE from_id <> excluded.from_id OR to_id <> excluded.to_id
 			`)
 			if err != nil {
 				return nil, fmt.Errorf("failed to prepare insert statement for issue %s changelog: %w", issue.Key, err)
 			}
 
-			_, err = stmt.Exec(
+			res, err := stmt.Exec(
+				instance,
 				issue.Key,
 				author,
 				date,
 				item.Field,
 				item.FromString,
 				item.ToString,
+				item.From,
+				item.To,
+				change.ID,
 			)
 			if err != nil {
 				return nil, fmt.Errorf("failed to insert issue %s changelog: %w", issue.Key, err)
 			}
 			stmt.Close() //nolint:errcheck,gosec
 
-			count++
+			// only count new events, and ones we already had that were missing their ids
+			if n, err := res.RowsAffected(); err == nil && n > 0 {
+				count++
+			}
 		}
 	}
 
 	return &count, nil
 }
 
+// claimEvent gives an event cached before history ids the id of the history it came from. when the history is already
+// cached from a webhook the older event is removed instead, leaving the history's to be corrected by the insert
+func (cache Cache) claimEvent(instance, key, historyID, author string, date time.Time, item *models.IssueChangelogHistoryItemScheme) error {
+	match := `instance = ? AND key = ? AND history_id = '' AND author = ? AND date = ? AND field = ? AND [from] = ? AND [to] = ?`
+	args := []any{instance, key, author, date, item.Field, item.FromString, item.ToString}
+
+	var cached int
+	err := cache.DB.QueryRow(`SELECT COUNT(*) FROM events WHERE instance = ? AND key = ? AND history_id = ? AND field = ?`, instance, key, historyID, item.Field).Scan(&cached)
+	if err != nil {
+		return err
+	}
+
+	if cached > 0 {
+		_, err = cache.DB.Exec(`DELETE FROM events WHERE `+match, args...)
+		return err
+	}
+
+	_, err = cache.DB.Exec(`UPDATE events SET history_id = ? WHERE `+match, append([]any{historyID}, args...)...)
+	return err
+}
+
 func (cache Cache) QueryForEvents(qfmt string, a ...any) ([]Event, error) {
 	q := fmt.Sprintf(qfmt, a...)
 
@@ -100,12 +188,16 @@ func (cache Cache) QueryForEvents(qfmt string, a ...any) ([]Event, error) {
 		e := Event{}
 		err = rows.Scan(
 			&e.ID,
+			&e.Instance,
 			&e.Key,
 			&e.Author,
 			&e.Date,
 			&e.Field,
 			&e.From,
 			&e.To,
+			&e.FromID,
+			&e.ToID,
+			&e.HistoryID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan events: %w", err)
@@ -121,14 +213,15 @@ func (cache Cache) QueryForEvents(qfmt string, a ...any) ([]Event, error) {
 	return events, nil
 }
 
-func (cache Cache) GetIssueEventsForField(key, field string) ([]Event, error) {
+func (cache Cache) GetIssueEventsForField(instance, key, field string) ([]Event, error) {
 	rows, err := cache.DB.Query(fmt.Sprintf(`
 		SELECT %s
 		FROM events
 		WHERE
-			key='%s' AND
-		    field='%s' ORDER BY date
-	`, EventColumnsString(), key, field))
+			instance=%s AND
+			key=%s AND
+		    field=%s ORDER BY date
+	`, EventColumnsString(), sqlString(instance), sqlString(key), sqlString(field)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events for issue %s for field %s: %w", key, field, err)
 	}
@@ -139,12 +232,16 @@ func (cache Cache) GetIssueEventsForField(key, field string) ([]Event, error) {
 		e := Event{}
 		err = rows.Scan(
 			&e.ID,
+			&e.Instance,
 			&e.Key,
 			&e.Author,
 			&e.Date,
 			&e.Field,
 			&e.From,
 			&e.To,
+			&e.FromID,
+			&e.ToID,
+			&e.HistoryID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan events for issue %s for field %s: %w", key, field, err)
@@ -160,8 +257,76 @@ func (cache Cache) GetIssueEventsForField(key, field string) ([]Event, error) {
 	return events, nil
 }
 
+// GetIssueEvents returns every event of an issue in the order they happened
+func (cache Cache) GetIssueEvents(instance, key string) ([]Event, error) {
+	return cache.QueryForEvents(`
+		SELECT %s
+		FROM events
+		WHERE
+			instance=%s AND
+			key=%s
+		ORDER BY date, id
+	`, EventColumnsString(), sqlString(instance), sqlString(key))
+}
+
 func (cache Cache) GetAllEvents() ([]Event, error) {
 	return cache.QueryForEvents(`
 		SELECT %s FROM events
 	`, EventColumnsString())
 }
+
+// EachEvent calls fn with each event in the date range of the issues the cache is filtered to, ordered by issue and
+// date, one row at a time rather than loading them all. zero times leave that end of the range open
+func (cache Cache) EachEvent(from, to time.Time, fn func(Event) error) error {
+	rangeClause := "1=1"
+	if !from.IsZero() {
+		rangeClause += fmt.Sprintf(" AND date >= '%s'", from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		rangeClause += fmt.Sprintf(" AND date < '%s'", to.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	q := fmt.Sprintf(`
+		SELECT %s FROM events
+		WHERE
+		    %s AND
+		    (instance, key) IN (SELECT instance, key FROM issues WHERE %s)
+		ORDER BY instance, key, date
+	`, EventColumnsString(), rangeClause, cache.filterClause())
+
+	rows, err := cache.DB.Query(q)
+	if err != nil {
+		return fmt.Errorf("failed to prepare event query '%s': %w", q, err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		e := Event{}
+		err = rows.Scan(
+			&e.ID,
+			&e.Instance,
+			&e.Key,
+			&e.Author,
+			&e.Date,
+			&e.Field,
+			&e.From,
+			&e.To,
+			&e.FromID,
+			&e.ToID,
+			&e.HistoryID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan events: %w", err)
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed iterating event rows: %w", err)
+	}
+
+	return nil
+}
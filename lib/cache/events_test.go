@@ -0,0 +1,46 @@
+package cache_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+func TestUpsertEventsClaimsEventsWithoutHistoryIDs(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	issue := jiratest.NewIssue("AB-1", "To Do", created).Transition(created.Add(time.Hour), "To Do", "In Progress")
+
+	// cached before history ids
+	history := issue.Changelog.Histories[0].ID
+	issue.Changelog.Histories[0].ID = ""
+	if _, err := theCache.UpsertEventsFromIssue(cache.DefaultInstance, issue.IssueScheme); err != nil {
+		t.Fatalf("caching events: %v", err)
+	}
+
+	issue.Changelog.Histories[0].ID = history
+	count, err := theCache.UpsertEventsFromIssue(cache.DefaultInstance, issue.IssueScheme)
+	if err != nil {
+		t.Fatalf("caching events: %v", err)
+	}
+	if *count != 0 {
+		t.Errorf("expected no new events, got %d", *count)
+	}
+
+	events, err := theCache.GetIssueEvents(cache.DefaultInstance, "AB-1")
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if len(events) != 1 || events[0].HistoryID != history {
+		t.Errorf("expected the cached event to get its history id, got %+v", events)
+	}
+}
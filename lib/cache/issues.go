@@ -10,7 +10,7 @@ import (
 	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 )
 
-var IssueColumns = []string{"key", "url", "type", "status", "resolution", "summary", "labels", "creator", "created", "updated", "daysopen"}
+var IssueColumns = []string{"instance", "key", "url", "type", "status", "resolution", "summary", "labels", "creator", "created", "updated", "daysopen", "missing", "assignee", "reporter", "priority", "parent"}
 
 func IssueColumnsString() string {
 	return strings.Join(IssueColumns, ", ")
@@ -37,9 +37,52 @@ const CreateIssuesTableSQL = `
 	)
 `
 
+// issues no longer returned by the jql (deleted or moved) are tombstoned with the time we noticed
+const AddIssuesMissingColumnSQL = `
+	ALTER TABLE "issues" ADD COLUMN "missing" DATE
+`
+
+// keys are only unique within a jira instance, so the primary key becomes (instance, key) which sqlite can only do by
+// rebuilding the table
+const MigrateIssuesInstanceSQL = `
+	CREATE TABLE "issues_instance" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "url" CHAR(256) NOT NULL,
+	    "type" CHAR(32) NOT NULL,
+	    "status" CHAR(32) NOT NULL,
+	    "resolution" CHAR(32) NOT NULL,
+	    "summary" CHAR(256) NOT NULL,
+	    "labels" VARCHAR(256) NOT NULL,
+	    "creator" CHAR(64) NOT NULL,
+	    "created" DATE NOT NULL,
+	    "updated" DATE NOT NULL,
+	    "daysopen" REAL,
+	    "missing" DATE,
+	    PRIMARY KEY (instance, key)
+	);
+	INSERT INTO issues_instance (instance, key, url, type, status, resolution, summary, labels, creator, created, updated, daysopen, missing)
+	SELECT '` + DefaultInstance + `', key, url, type, status, resolution, summary, labels, creator, created, updated, daysopen, missing FROM issues;
+	DROP TABLE issues;
+	ALTER TABLE issues_instance RENAME TO issues;
+`
+
+// assignee, reporter and priority were added after instances, so existing issues have them empty until fetched again
+const AddIssuesPeopleColumnsSQL = `
+	ALTER TABLE "issues" ADD COLUMN "assignee" CHAR(64) NOT NULL DEFAULT '';
+	ALTER TABLE "issues" ADD COLUMN "reporter" CHAR(64) NOT NULL DEFAULT '';
+	ALTER TABLE "issues" ADD COLUMN "priority" CHAR(32) NOT NULL DEFAULT '';
+`
+
+// the key of the issue's parent, its epic or for sub-tasks the issue they are part of
+const AddIssuesParentColumnSQL = `
+	ALTER TABLE "issues" ADD COLUMN "parent" CHAR(16) NOT NULL DEFAULT '';
+`
+
 type Issue struct {
-	Key string
-	URL string
+	Instance string
+	Key      string
+	URL      string
 
 	Type   string
 	Status string
@@ -47,22 +90,34 @@ type Issue struct {
 	Resolution string
 
 	Summary string
-	Labels  []string
 
-	Creator string
-	Created time.Time
-	Updated time.Time
+	// from the issue_labels, issue_components and issue_versions tables
+	Labels           []string
+	Components       []string
+	FixVersions      []string
+	AffectedVersions []string
+
+	Creator  string
+	Assignee string // empty when unassigned
+	Reporter string
+	Priority string
+	Parent   string // empty without one
+	Created  time.Time
+	Updated  time.Time
 
 	// calculated
 	DaysOpen sql.NullFloat64
 	Closed   time.Time // todo, need to parse events to get this
+
+	// set when the issue stopped being returned by the jql
+	Missing sql.NullTime
 }
 
 func (i Issue) IsClosed() bool {
 	return i.Status == "Closed"
 }
 
-func (cache Cache) UpsertIssueFromJIRA(issue *models.IssueScheme) error {
+func (cache Cache) UpsertIssueFromJIRA(instance string, issue *models.IssueScheme) error {
 	stmt, err := cache.DB.Prepare(fmt.Sprintf(`
 		INSERT OR REPLACE INTO issues (%s)
 		VALUES (%s)
@@ -97,6 +152,26 @@ func (cache Cache) UpsertIssueFromJIRA(issue *models.IssueScheme) error {
 		creatorName = issue.Fields.Creator.DisplayName
 	}
 
+	assigneeName := ""
+	if issue.Fields.Assignee != nil {
+		assigneeName = issue.Fields.Assignee.DisplayName
+	}
+
+	reporterName := ""
+	if issue.Fields.Reporter != nil {
+		reporterName = issue.Fields.Reporter.DisplayName
+	}
+
+	priority := ""
+	if issue.Fields.Priority != nil {
+		priority = issue.Fields.Priority.Name
+	}
+
+	parent := ""
+	if issue.Fields.Parent != nil {
+		parent = issue.Fields.Parent.Key
+	}
+
 	createdDate, err := time.Parse("2006-01-02T15:04:05.000-0700", issue.Fields.Created)
 	if err != nil {
 		return fmt.Errorf("failed to parse Created date %s: %w", issue.Fields.Created, err)
@@ -106,6 +181,7 @@ func (cache Cache) UpsertIssueFromJIRA(issue *models.IssueScheme) error {
 		return fmt.Errorf("failed to parse Updated date %s: %w", issue.Fields.Updated, err)
 	}
 	_, err = stmt.Exec(
+		instance,
 		issue.Key,
 		fmt.Sprintf("%s://%s/browse/%s", parsedURL.Scheme, parsedURL.Host, issue.Key),
 		issueType,
@@ -116,16 +192,73 @@ func (cache Cache) UpsertIssueFromJIRA(issue *models.IssueScheme) error {
 		creatorName,
 		createdDate,
 		updatedDate,
-		0, // we calculate this after we get all events
+		0,   // we calculate this after we get all events
+		nil, // seen again so no longer missing
+		assigneeName,
+		reporterName,
+		priority,
+		parent,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert issue %s: %w", issue.Key, err)
 	}
 	stmt.Close() //nolint:errcheck,gosec
 
+	if err := cache.upsertIssueLabels(instance, issue); err != nil {
+		return err
+	}
+
+	if err := cache.upsertIssueLinks(instance, issue); err != nil {
+		return err
+	}
+
+	return cache.upsertIssueComponents(instance, issue)
+}
+
+// DeleteIssue removes an issue, all its events, dataset links, labels, components, versions, issue links and sprints
+func (cache Cache) DeleteIssue(instance, key string) error {
+	if _, err := cache.DB.Exec(`DELETE FROM events WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete events for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM dataset_issues WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete dataset links for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issue_labels WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete labels for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issue_components WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete components for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issue_versions WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete versions for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issue_links WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete links for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issue_sprints WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete sprints for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issue_numbers WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete number fields for issue %s: %w", key, err)
+	}
+
+	if _, err := cache.DB.Exec(`DELETE FROM issues WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to delete issue %s: %w", key, err)
+	}
+
 	return nil
 }
 
+// issueListsBatchSize is how many issues' labels, components and versions are read at once
+const issueListsBatchSize = 500
+
 func (cache Cache) QueryForIssues(qfmt string, a ...any) (*[]Issue, error) {
 	q := fmt.Sprintf(qfmt, a...)
 
@@ -135,47 +268,137 @@ func (cache Cache) QueryForIssues(qfmt string, a ...any) (*[]Issue, error) {
 	}
 	defer rows.Close() //nolint:errcheck
 
-	issues := make([]Issue, 0)
-
-	var labels string
+	var scanned []*Issue
 	for rows.Next() {
-		issue := Issue{}
-		err = rows.Scan(
-			&issue.Key,
-			&issue.URL,
-			&issue.Type,
-			&issue.Status,
-			&issue.Resolution,
-			&issue.Summary,
-			&labels,
-			&issue.Creator,
-			&issue.Created,
-			&issue.Updated,
-			&issue.DaysOpen,
-		)
+		issue, err := scanIssue(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan issue: %w", err)
+			return nil, err
 		}
-
-		issue.Labels = strings.Split(labels, ", ")
-
-		issues = append(issues, issue)
+		scanned = append(scanned, issue)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed iterating issue rows: %w", err)
 	}
 
+	// only the lists of the issues returned, in batches so the query stays a reasonable size
+	for start := 0; start < len(scanned); start += issueListsBatchSize {
+		if err := cache.applyIssueLists(scanned[start:min(start+issueListsBatchSize, len(scanned))]); err != nil {
+			return nil, err
+		}
+	}
+
+	issues := make([]Issue, 0, len(scanned))
+	for _, i := range scanned {
+		issues = append(issues, *i)
+	}
+
 	return &issues, nil
 }
 
-func (cache Cache) GetIssue(key string) (*Issue, error) {
+func scanIssue(rows *sql.Rows) (*Issue, error) {
+	issue := Issue{}
+	var labels string // still written for older versions, labels are read from issue_labels
+	err := rows.Scan(
+		&issue.Instance,
+		&issue.Key,
+		&issue.URL,
+		&issue.Type,
+		&issue.Status,
+		&issue.Resolution,
+		&issue.Summary,
+		&labels,
+		&issue.Creator,
+		&issue.Created,
+		&issue.Updated,
+		&issue.DaysOpen,
+		&issue.Missing,
+		&issue.Assignee,
+		&issue.Reporter,
+		&issue.Priority,
+		&issue.Parent,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan issue: %w", err)
+	}
+
+	issue.Labels = []string{}
+
+	return &issue, nil
+}
+
+// EachIssue calls fn with each issue created by the end of the day to, a batch of rows at a time rather than loading
+// them all. a zero to is every issue. when an issue closed depends on the status model, so it is up to callers to drop
+// those closed before a range starts
+func (cache Cache) EachIssue(to time.Time, fn func(Issue) error) error {
+	rangeClause := "1=1"
+	if !to.IsZero() {
+		rangeClause += fmt.Sprintf(" AND created < '%s'", to.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	q := fmt.Sprintf(`
+		SELECT %s FROM issues
+		WHERE
+		    %s AND
+		    %s
+		ORDER BY instance, key
+	`, IssueColumnsString(), rangeClause, cache.filterClause())
+
+	rows, err := cache.DB.Query(q)
+	if err != nil {
+		return fmt.Errorf("failed to prepare issue query '%s': %w", q, err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	// lists are read a batch of issues at a time so only the batch is ever held in memory
+	batch := make([]*Issue, 0, issueListsBatchSize)
+	flush := func() error {
+		if err := cache.applyIssueLists(batch); err != nil {
+			return err
+		}
+		for _, i := range batch {
+			if err := fn(*i); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return err
+		}
+
+		if batch = append(batch, issue); len(batch) == issueListsBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed iterating issue rows: %w", err)
+	}
+
+	return flush()
+}
+
+// GetIssue returns an issue by its current or any previous key
+func (cache Cache) GetIssue(instance, key string) (*Issue, error) {
+	key, err := cache.ResolveKey(instance, key)
+	if err != nil {
+		return nil, err
+	}
+
 	issues, err := cache.QueryForIssues(`
 	SELECT %s
 	FROM issues
 	WHERE
-	    key = '%s'
-	`, IssueColumnsString(), key)
+	    instance = %s AND
+	    key = %s
+	`, IssueColumnsString(), sqlString(instance), sqlString(key))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for issue %s: %w", key, err)
 	}
@@ -188,18 +411,64 @@ func (cache Cache) GetIssue(key string) (*Issue, error) {
 	return &issue, nil
 }
 
+// filterClause limits issues to the cache's instance, dataset, labels, components and fix versions and excludes
+// tombstoned issues unless the cache was opened to include them
+func (cache Cache) filterClause() string {
+	clauses := []string{"1=1"}
+
+	if cache.Instance != "" {
+		clauses = append(clauses, "instance = "+sqlString(cache.Instance))
+	}
+
+	if !cache.IncludeMissing {
+		clauses = append(clauses, "missing IS NULL")
+	}
+
+	if cache.Dataset != "" {
+		datasetMissing := ""
+		if !cache.IncludeMissing {
+			datasetMissing = " AND missing IS NULL"
+		}
+		clauses = append(clauses, fmt.Sprintf("(instance, key) IN (SELECT instance, key FROM dataset_issues WHERE dataset = %s%s)", sqlString(cache.Dataset), datasetMissing))
+	}
+
+	clauses = append(clauses, cache.listClause()...)
+
+	return strings.Join(clauses, " AND ")
+}
+
 func (cache Cache) GetAllIssues() (*[]Issue, error) {
 	return cache.QueryForIssues(`
 		SELECT %s FROM issues
-	`, IssueColumnsString())
+		WHERE %s
+	`, IssueColumnsString(), cache.filterClause())
 }
 
 func (cache Cache) GetIssuesCreatedInDateRange(from, to time.Time) (*[]Issue, error) {
 	return cache.QueryForIssues(`
 		SELECT %s FROM issues
 		WHERE
-		    created BETWEEN '%s' AND '%s'
-	`, IssueColumnsString(), from.Format("2006-01-02"), to.Format("2006-01-02"))
+		    created BETWEEN '%s' AND '%s' AND
+		    %s
+	`, IssueColumnsString(), from.Format("2006-01-02"), to.Format("2006-01-02"), cache.filterClause())
+}
+
+func (cache Cache) GetMissingIssues() (*[]Issue, error) {
+	datasetClause := "1=1"
+	if cache.Instance != "" {
+		datasetClause += " AND instance = " + sqlString(cache.Instance)
+	}
+	if cache.Dataset != "" {
+		datasetClause += fmt.Sprintf(" AND (instance, key) IN (SELECT instance, key FROM dataset_issues WHERE dataset = %s)", sqlString(cache.Dataset))
+	}
+
+	return cache.QueryForIssues(`
+		SELECT %s FROM issues
+		WHERE
+		    missing IS NOT NULL AND
+		    %s
+		ORDER BY missing, instance, key
+	`, IssueColumnsString(), datasetClause)
 }
 
 /*
@@ -0,0 +1,79 @@
+package cache_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/cache"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var created = time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+
+func TestEachIssueLists(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	// more than one batch, each issue with its own component
+	const n = 1100
+	for k := 0; k < n; k++ {
+		key := fmt.Sprintf("AB-%d", k)
+		issue := jiratest.NewIssue(key, "To Do", created).Component("c-" + key).FixVersion("v1")
+		if err := theCache.UpsertIssueFromJIRA(cache.DefaultInstance, issue.IssueScheme); err != nil {
+			t.Fatalf("caching %s: %v", key, err)
+		}
+	}
+
+	seen := 0
+	err = theCache.EachIssue(time.Time{}, func(i cache.Issue) error {
+		seen++
+		if len(i.Components) != 1 || i.Components[0] != "c-"+i.Key {
+			return fmt.Errorf("expected %s to have component c-%s, got %v", i.Key, i.Key, i.Components)
+		}
+		if len(i.FixVersions) != 1 || i.FixVersions[0] != "v1" {
+			return fmt.Errorf("expected %s to have fix version v1, got %v", i.Key, i.FixVersions)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != n {
+		t.Errorf("expected %d issues, got %d", n, seen)
+	}
+}
+
+func TestQueryForIssuesLists(t *testing.T) {
+	t.Parallel()
+
+	theCache, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("opening cache: %v", err)
+	}
+	defer theCache.DB.Close() //nolint:errcheck
+
+	for _, issue := range []*jiratest.Issue{
+		jiratest.NewIssue("AB-1", "To Do", created).Component("api"),
+		jiratest.NewIssue("AB-2", "To Do", created).Component("web").AffectedVersion("v1"),
+	} {
+		if err := theCache.UpsertIssueFromJIRA(cache.DefaultInstance, issue.IssueScheme); err != nil {
+			t.Fatalf("caching %s: %v", issue.Key, err)
+		}
+	}
+
+	i, err := theCache.GetIssue(cache.DefaultInstance, "AB-2")
+	if err != nil {
+		t.Fatalf("reading AB-2: %v", err)
+	}
+	if len(i.Components) != 1 || i.Components[0] != "web" || len(i.AffectedVersions) != 1 || i.AffectedVersions[0] != "v1" {
+		t.Errorf("expected AB-2 to have only its own lists, got %+v", i)
+	}
+}
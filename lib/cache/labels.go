@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// labels were a ", " joined column which can't be filtered on and breaks labels containing commas, so each gets a row
+const CreateIssueLabelsTableSQL = `
+	CREATE TABLE "issue_labels" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "label" CHAR(64) NOT NULL,
+	    PRIMARY KEY (instance, key, label)
+	)
+`
+
+// existing issues are split out of the joined column, the best we can do until they are fetched again
+const MigrateIssueLabelsSQL = `
+	WITH RECURSIVE split(instance, key, label, rest) AS (
+		SELECT instance, key, '', labels || ', ' FROM issues
+		UNION ALL
+		SELECT instance, key, substr(rest, 1, instr(rest, ', ') - 1), substr(rest, instr(rest, ', ') + 2) FROM split WHERE rest <> ''
+	)
+	INSERT OR IGNORE INTO issue_labels (instance, key, label)
+	SELECT instance, key, label FROM split WHERE label <> ''
+`
+
+// upsertIssueLabels replaces an issue's labels with those from jira
+func (cache Cache) upsertIssueLabels(instance string, issue *models.IssueScheme) error {
+	if _, err := cache.DB.Exec(`DELETE FROM issue_labels WHERE instance = ? AND key = ?`, instance, issue.Key); err != nil {
+		return fmt.Errorf("failed to clear labels for issue %s: %w", issue.Key, err)
+	}
+
+	for _, label := range issue.Fields.Labels {
+		if label == "" {
+			continue
+		}
+		_, err := cache.DB.Exec(`INSERT OR IGNORE INTO issue_labels (instance, key, label) VALUES (?, ?, ?)`, instance, issue.Key, label)
+		if err != nil {
+			return fmt.Errorf("failed to insert label %s for issue %s: %w", label, issue.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// GetLabels returns every label of the issues the cache is filtered to with how many issues have it
+func (cache Cache) GetLabels() (map[string]int, error) {
+	rows, err := cache.DB.Query(fmt.Sprintf(`
+		SELECT label, COUNT(*) FROM issue_labels
+		WHERE (instance, key) IN (SELECT instance, key FROM issues WHERE %s)
+		GROUP BY label
+	`, cache.filterClause()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query labels: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	labels := map[string]int{}
+	for rows.Next() {
+		var label string
+		var n int
+		if err = rows.Scan(&label, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan labels: %w", err)
+		}
+		labels[label] = n
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating labels: %w", err)
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// links are stored from both ends as jira returns them on each issue, so AB-1 blocks AB-2 is a row on AB-1 with the
+// outward relation and one on AB-2 with the inward. linked_status is the other issue's status when last fetched
+const CreateIssueLinksTableSQL = `
+	CREATE TABLE "issue_links" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "type" CHAR(64) NOT NULL,
+	    "direction" CHAR(8) NOT NULL,
+	    "relation" CHAR(64) NOT NULL,
+	    "linked_key" CHAR(16) NOT NULL,
+	    "linked_status" CHAR(64) NOT NULL,
+	    PRIMARY KEY (instance, key, type, direction, linked_key)
+	)
+`
+
+const (
+	LinkInward  = "inward"
+	LinkOutward = "outward"
+)
+
+// Link is one end of an issue link, Relation is how jira describes it from this issue such as "is blocked by"
+type Link struct {
+	Instance     string
+	Key          string
+	Type         string
+	Direction    string
+	Relation     string
+	LinkedKey    string
+	LinkedStatus string
+}
+
+// upsertIssueLinks replaces an issue's links with those from jira
+func (cache Cache) upsertIssueLinks(instance string, issue *models.IssueScheme) error {
+	if _, err := cache.DB.Exec(`DELETE FROM issue_links WHERE instance = ? AND key = ?`, instance, issue.Key); err != nil {
+		return fmt.Errorf("failed to clear links for issue %s: %w", issue.Key, err)
+	}
+
+	for _, l := range issue.Fields.IssueLinks {
+		if l == nil || l.Type == nil {
+			continue
+		}
+
+		direction, relation, linked := LinkOutward, l.Type.Outward, l.OutwardIssue
+		if l.InwardIssue != nil {
+			direction, relation, linked = LinkInward, l.Type.Inward, l.InwardIssue
+		}
+		if linked == nil || linked.Key == "" {
+			continue
+		}
+
+		status := ""
+		if linked.Fields != nil && linked.Fields.Status != nil {
+			status = linked.Fields.Status.Name
+		}
+
+		_, err := cache.DB.Exec(`
+			INSERT OR REPLACE INTO issue_links (instance, key, type, direction, relation, linked_key, linked_status)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, instance, issue.Key, l.Type.Name, direction, relation, linked.Key, status)
+		if err != nil {
+			return fmt.Errorf("failed to insert link %s %s for issue %s: %w", relation, linked.Key, issue.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// GetLinks returns the links of every listed issue
+func (cache Cache) GetLinks() ([]Link, error) {
+	return cache.queryLinks(fmt.Sprintf(`
+		SELECT instance, key, type, direction, relation, linked_key, linked_status FROM issue_links
+		WHERE (instance, key) IN (SELECT instance, key FROM issues WHERE %s)
+		ORDER BY instance, key, type, direction, linked_key
+	`, cache.filterClause()))
+}
+
+// GetIssueLinks returns the links of one issue
+func (cache Cache) GetIssueLinks(instance, key string) ([]Link, error) {
+	return cache.queryLinks(fmt.Sprintf(`
+		SELECT instance, key, type, direction, relation, linked_key, linked_status FROM issue_links
+		WHERE instance = %s AND key = %s
+		ORDER BY type, direction, linked_key
+	`, sqlString(instance), sqlString(key)))
+}
+
+func (cache Cache) queryLinks(q string) ([]Link, error) {
+	rows, err := cache.DB.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var links []Link
+	for rows.Next() {
+		l := Link{}
+		if err = rows.Scan(&l.Instance, &l.Key, &l.Type, &l.Direction, &l.Relation, &l.LinkedKey, &l.LinkedStatus); err != nil {
+			return nil, fmt.Errorf("failed to scan links: %w", err)
+		}
+		links = append(links, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating links: %w", err)
+	}
+
+	return links, nil
+}
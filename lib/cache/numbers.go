@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"fmt"
+)
+
+// number fields are custom fields such as story points mapped to a friendly name per instance, with the name jira
+// gives the field in changelogs so the history of their values can be found in the events
+const CreateNumberFieldsTableSQL = `
+	CREATE TABLE "number_fields" (
+	    "instance" CHAR(64) NOT NULL,
+	    "name" CHAR(64) NOT NULL,
+	    "field_id" CHAR(32) NOT NULL,
+	    "field_name" CHAR(64) NOT NULL,
+	    PRIMARY KEY (instance, name)
+	)
+`
+
+const CreateIssueNumbersTableSQL = `
+	CREATE TABLE "issue_numbers" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "name" CHAR(64) NOT NULL,
+	    "value" REAL NOT NULL,
+	    PRIMARY KEY (instance, key, name)
+	)
+`
+
+type NumberField struct {
+	Instance  string
+	Name      string
+	FieldID   string
+	FieldName string // as it appears in changelogs, empty when it couldn't be looked up
+}
+
+// UpsertNumberField records which custom field a friendly name is on an instance
+func (cache Cache) UpsertNumberField(instance, name, fieldID, fieldName string) error {
+	_, err := cache.DB.Exec(`
+		INSERT INTO number_fields (instance, name, field_id, field_name) VALUES (?, ?, ?, ?)
+		ON CONFLICT (instance, name) DO UPDATE SET field_id = excluded.field_id, field_name = excluded.field_name
+	`, instance, name, fieldID, fieldName)
+	if err != nil {
+		return fmt.Errorf("failed to upsert number field %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// GetNumberFields returns the number fields of every instance, or the one the cache is filtered to
+func (cache Cache) GetNumberFields() ([]NumberField, error) {
+	instanceClause := "1=1"
+	if cache.Instance != "" {
+		instanceClause = "instance = " + sqlString(cache.Instance)
+	}
+
+	rows, err := cache.DB.Query(fmt.Sprintf(`
+		SELECT instance, name, field_id, field_name FROM number_fields
+		WHERE %s
+		ORDER BY instance, name
+	`, instanceClause))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query number fields: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var fields []NumberField
+	for rows.Next() {
+		var f NumberField
+		if err = rows.Scan(&f.Instance, &f.Name, &f.FieldID, &f.FieldName); err != nil {
+			return nil, fmt.Errorf("failed to scan number fields: %w", err)
+		}
+		fields = append(fields, f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating number fields: %w", err)
+	}
+
+	return fields, nil
+}
+
+// UpsertIssueNumbers replaces an issue's number field values with those it has now, unset fields have no row
+func (cache Cache) UpsertIssueNumbers(instance, key string, numbers map[string]float64) error {
+	if _, err := cache.DB.Exec(`DELETE FROM issue_numbers WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to clear number fields for issue %s: %w", key, err)
+	}
+
+	for name, value := range numbers {
+		_, err := cache.DB.Exec(`INSERT INTO issue_numbers (instance, key, name, value) VALUES (?, ?, ?, ?)`, instance, key, name, value)
+		if err != nil {
+			return fmt.Errorf("failed to insert %s for issue %s: %w", name, key, err)
+		}
+	}
+
+	return nil
+}
+
+// GetNumbers returns the value of a number field for every issue that has it, keyed by instance/key
+func (cache Cache) GetNumbers(name string) (map[string]float64, error) {
+	return cache.queryNumbers(fmt.Sprintf(`
+		SELECT instance || '/' || key, value FROM issue_numbers
+		WHERE name = %s
+	`, sqlString(name)))
+}
+
+// GetIssueNumbers returns the value of each number field an issue has, keyed by name
+func (cache Cache) GetIssueNumbers(instance, key string) (map[string]float64, error) {
+	return cache.queryNumbers(fmt.Sprintf(`
+		SELECT name, value FROM issue_numbers
+		WHERE instance = %s AND key = %s
+	`, sqlString(instance), sqlString(key)))
+}
+
+func (cache Cache) queryNumbers(q string) (map[string]float64, error) {
+	rows, err := cache.DB.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query number fields: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	numbers := map[string]float64{}
+	for rows.Next() {
+		var k string
+		var v float64
+		if err = rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("failed to scan number fields: %w", err)
+		}
+		numbers[k] = v
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating number fields: %w", err)
+	}
+
+	return numbers, nil
+}
+
+// GetNumberEvents returns the changes to a number field on every instance it is mapped on, ordered by issue and date
+func (cache Cache) GetNumberEvents(name string) ([]Event, error) {
+	return cache.QueryForEvents(`
+		SELECT %s FROM events
+		WHERE (instance, field) IN (SELECT instance, field_name FROM number_fields WHERE name = %s AND field_name <> '')
+		ORDER BY instance, key, date, id
+	`, EventColumnsString(), sqlString(name))
+}
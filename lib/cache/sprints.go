@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// boards are the scrum boards of the projects issues are fetched from, their sprints are fetched with them
+const CreateBoardsTableSQL = `
+	CREATE TABLE "boards" (
+	    "instance" CHAR(64) NOT NULL,
+	    "id" INTEGER NOT NULL,
+	    "name" CHAR(128) NOT NULL,
+	    "type" CHAR(16) NOT NULL,
+	    "project" CHAR(16) NOT NULL,
+	    PRIMARY KEY (instance, id)
+	)
+`
+
+// sprint dates are empty until jira has them, future sprints have none and open ones no complete date
+const CreateSprintsTableSQL = `
+	CREATE TABLE "sprints" (
+	    "instance" CHAR(64) NOT NULL,
+	    "id" INTEGER NOT NULL,
+	    "board_id" INTEGER NOT NULL,
+	    "name" CHAR(128) NOT NULL,
+	    "state" CHAR(16) NOT NULL,
+	    "goal" TEXT NOT NULL,
+	    "start_date" DATETIME,
+	    "end_date" DATETIME,
+	    "complete_date" DATETIME,
+	    PRIMARY KEY (instance, id)
+	)
+`
+
+// the sprints an issue is in now, from the sprint field. which it was in when is replayed from its Sprint events
+const CreateIssueSprintsTableSQL = `
+	CREATE TABLE "issue_sprints" (
+	    "instance" CHAR(64) NOT NULL,
+	    "key" CHAR(16) NOT NULL,
+	    "sprint_id" INTEGER NOT NULL,
+	    PRIMARY KEY (instance, key, sprint_id)
+	)
+`
+
+type Sprint struct {
+	Instance     string
+	ID           int
+	BoardID      int
+	Name         string
+	State        string // future, active or closed
+	Goal         string
+	StartDate    sql.NullTime
+	EndDate      sql.NullTime
+	CompleteDate sql.NullTime
+}
+
+// UpsertBoard adds or updates a board of a project
+func (cache Cache) UpsertBoard(instance, project string, b *models.BoardScheme) error {
+	_, err := cache.DB.Exec(`
+		INSERT OR REPLACE INTO boards (instance, id, name, type, project)
+		VALUES (?, ?, ?, ?, ?)
+	`, instance, b.ID, b.Name, b.Type, project)
+	if err != nil {
+		return fmt.Errorf("failed to insert board %d: %w", b.ID, err)
+	}
+
+	return nil
+}
+
+// UpsertBoardSprints adds or updates the sprints of a board from the agile api
+func (cache Cache) UpsertBoardSprints(instance string, board int, sprints []*models.BoardSprintScheme) error {
+	for _, s := range sprints {
+		_, err := cache.DB.Exec(`
+			INSERT OR REPLACE INTO sprints (instance, id, board_id, name, state, goal, start_date, end_date, complete_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, instance, s.ID, board, s.Name, s.State, s.Goal, nullTime(s.StartDate), nullTime(s.EndDate), nullTime(s.CompleteDate))
+		if err != nil {
+			return fmt.Errorf("failed to insert sprint %d of board %d: %w", s.ID, board, err)
+		}
+	}
+
+	return nil
+}
+
+// sprintStateOrder ranks a sprint's state column, sprints only ever move from future to active to closed
+const sprintStateOrder = `CASE %s WHEN 'closed' THEN 2 WHEN 'active' THEN 1 ELSE 0 END`
+
+// UpsertIssueSprints replaces the sprints an issue is in with those of its sprint field. the field's copy of a sprint
+// updates the cached one unless the agile api has already seen it further along, so boards we can't read the sprints of
+// still have them and renames and state changes between fetches of the boards show up
+func (cache Cache) UpsertIssueSprints(instance, key string, sprints []*models.SprintDetailScheme) error {
+	if _, err := cache.DB.Exec(`DELETE FROM issue_sprints WHERE instance = ? AND key = ?`, instance, key); err != nil {
+		return fmt.Errorf("failed to clear sprints for issue %s: %w", key, err)
+	}
+
+	for _, s := range sprints {
+		_, err := cache.DB.Exec(fmt.Sprintf(`
+			INSERT INTO sprints (instance, id, board_id, name, state, goal, start_date, end_date, complete_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (instance, id) DO UPDATE SET
+				name = excluded.name,
+				state = excluded.state,
+				goal = COALESCE(NULLIF(excluded.goal, ''), goal),
+				start_date = COALESCE(excluded.start_date, start_date),
+				end_date = COALESCE(excluded.end_date, end_date),
+				complete_date = COALESCE(excluded.complete_date, complete_date)
+			WHERE %s >= %s
+		`, fmt.Sprintf(sprintStateOrder, "excluded.state"), fmt.Sprintf(sprintStateOrder, "sprints.state")),
+			instance, s.ID, s.OriginBoardID, s.Name, s.State, s.Goal, parseSprintDate(s.StartDate), parseSprintDate(s.EndDate), parseSprintDate(s.CompleteDate))
+		if err != nil {
+			return fmt.Errorf("failed to insert sprint %d of issue %s: %w", s.ID, key, err)
+		}
+
+		if _, err := cache.DB.Exec(`INSERT OR IGNORE INTO issue_sprints (instance, key, sprint_id) VALUES (?, ?, ?)`, instance, key, s.ID); err != nil {
+			return fmt.Errorf("failed to insert sprint %d for issue %s: %w", s.ID, key, err)
+		}
+	}
+
+	return nil
+}
+
+// GetSprints returns the sprints of the cache's instance in start date order, future sprints last
+func (cache Cache) GetSprints() ([]Sprint, error) {
+	instanceClause := "1=1"
+	if cache.Instance != "" {
+		instanceClause = "instance = " + sqlString(cache.Instance)
+	}
+
+	return cache.querySprints(fmt.Sprintf(`
+		SELECT instance, id, board_id, name, state, goal, start_date, end_date, complete_date
+		FROM sprints
+		WHERE %s
+		ORDER BY start_date IS NULL, start_date, id
+	`, instanceClause))
+}
+
+// GetSprintsForIssue returns the sprints an issue is in now
+func (cache Cache) GetSprintsForIssue(instance, key string) ([]Sprint, error) {
+	return cache.querySprints(fmt.Sprintf(`
+		SELECT s.instance, s.id, s.board_id, s.name, s.state, s.goal, s.start_date, s.end_date, s.complete_date
+		FROM sprints s JOIN issue_sprints i ON i.instance = s.instance AND i.sprint_id = s.id
+		WHERE i.instance = %s AND i.key = %s
+		ORDER BY s.start_date IS NULL, s.start_date, s.id
+	`, sqlString(instance), sqlString(key)))
+}
+
+func (cache Cache) querySprints(q string) ([]Sprint, error) {
+	rows, err := cache.DB.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sprints: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var sprints []Sprint
+	for rows.Next() {
+		s := Sprint{}
+		if err = rows.Scan(&s.Instance, &s.ID, &s.BoardID, &s.Name, &s.State, &s.Goal, &s.StartDate, &s.EndDate, &s.CompleteDate); err != nil {
+			return nil, fmt.Errorf("failed to scan sprints: %w", err)
+		}
+		sprints = append(sprints, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating sprints: %w", err)
+	}
+
+	return sprints, nil
+}
+
+// GetIssueSprints returns the ids of the sprints each listed issue is in now keyed by instance/key
+func (cache Cache) GetIssueSprints() (map[string][]int, error) {
+	rows, err := cache.DB.Query(fmt.Sprintf(`
+		SELECT instance, key, sprint_id FROM issue_sprints
+		WHERE (instance, key) IN (SELECT instance, key FROM issues WHERE %s)
+		ORDER BY instance, key, sprint_id
+	`, cache.filterClause()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue sprints: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	sprints := map[string][]int{}
+	for rows.Next() {
+		var instance, key string
+		var id int
+		if err = rows.Scan(&instance, &key, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan issue sprints: %w", err)
+		}
+		sprints[instance+"/"+key] = append(sprints[instance+"/"+key], id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating issue sprints: %w", err)
+	}
+
+	return sprints, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// sprint field dates are strings, empty or unparseable ones are left for the agile api to fill in
+func parseSprintDate(s string) sql.NullTime {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return sql.NullTime{}
+	}
+	return nullTime(t)
+}
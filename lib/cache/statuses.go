@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// statuses are per instance with the category jira groups them into, To Do, In Progress or Done
+const CreateStatusesTableSQL = `
+	CREATE TABLE "statuses" (
+	    "instance" CHAR(64) NOT NULL,
+	    "name" CHAR(64) NOT NULL,
+	    "category" CHAR(32) NOT NULL,
+	    PRIMARY KEY (instance, name)
+	)
+`
+
+// UpsertStatuses replaces the statuses of an instance with those from jira
+func (cache Cache) UpsertStatuses(instance string, statuses []*models.StatusDetailScheme) error {
+	if _, err := cache.DB.Exec(`DELETE FROM statuses WHERE instance = ?`, instance); err != nil {
+		return fmt.Errorf("failed to clear statuses for instance %s: %w", instance, err)
+	}
+
+	for _, s := range statuses {
+		if s.StatusCategory == nil || s.StatusCategory.Name == "" {
+			continue
+		}
+
+		_, err := cache.DB.Exec(`
+			INSERT OR REPLACE INTO statuses (instance, name, category)
+			VALUES (?, ?, ?)
+		`, instance, s.Name, s.StatusCategory.Name)
+		if err != nil {
+			return fmt.Errorf("failed to insert status %s: %w", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetStatusCategories returns the category of each status keyed by instance/name
+func (cache Cache) GetStatusCategories() (map[string]string, error) {
+	rows, err := cache.DB.Query(`SELECT instance, name, category FROM statuses`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statuses: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	categories := map[string]string{}
+	for rows.Next() {
+		var instance, name, category string
+		if err = rows.Scan(&instance, &name, &category); err != nil {
+			return nil, fmt.Errorf("failed to scan statuses: %w", err)
+		}
+		categories[instance+"/"+name] = category
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating statuses: %w", err)
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const CreateSyncsTableSQL = `
+	CREATE TABLE IF NOT EXISTS "syncs" (
+	    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+	    "started" DATE NOT NULL,
+	    "duration" REAL NOT NULL,
+	    "issues" INTEGER NOT NULL,
+	    "error" VARCHAR(1024) NOT NULL
+	)
+`
+
+// Sync is a record of a single fetch run, so fetch health can be reported on by whatever reads the cache
+type Sync struct {
+	ID       int
+	Started  time.Time
+	Duration time.Duration
+	Issues   int
+	Error    string
+}
+
+func (s Sync) Succeeded() bool {
+	return s.Error == ""
+}
+
+func (cache Cache) InsertSync(started time.Time, duration time.Duration, issues int, syncErr error) error {
+	errString := ""
+	if syncErr != nil {
+		errString = syncErr.Error()
+	}
+
+	_, err := cache.DB.Exec(`
+		INSERT INTO syncs (started, duration, issues, error)
+		VALUES (?, ?, ?, ?)
+	`, started, duration.Seconds(), issues, errString)
+	if err != nil {
+		return fmt.Errorf("failed to insert sync: %w", err)
+	}
+
+	return nil
+}
+
+// SyncStats is the aggregate fetch health
+type SyncStats struct {
+	Runs        int
+	Errors      int
+	Last        *Sync
+	LastSuccess *Sync
+}
+
+func (cache Cache) GetSyncStats() (*SyncStats, error) {
+	stats := SyncStats{}
+
+	err := cache.DB.QueryRow(`
+		SELECT COUNT(*), COUNT(CASE WHEN error != '' THEN 1 END) FROM syncs
+	`).Scan(&stats.Runs, &stats.Errors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count syncs: %w", err)
+	}
+
+	if stats.Last, err = cache.querySync(`SELECT id, started, duration, issues, error FROM syncs ORDER BY started DESC LIMIT 1`); err != nil {
+		return nil, err
+	}
+
+	if stats.LastSuccess, err = cache.querySync(`SELECT id, started, duration, issues, error FROM syncs WHERE error = '' ORDER BY started DESC LIMIT 1`); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (cache Cache) querySync(q string) (*Sync, error) {
+	s := Sync{}
+	var seconds float64
+
+	err := cache.DB.QueryRow(q).Scan(&s.ID, &s.Started, &seconds, &s.Issues, &s.Error)
+	if err == sql.ErrNoRows { //nolint:errorlint // sql returns this unwrapped
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync: %w", err)
+	}
+	s.Duration = time.Duration(seconds * float64(time.Second))
+
+	return &s, nil
+}
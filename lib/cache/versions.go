@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// versions are per project, with the release date jira has for them if any
+const CreateVersionsTableSQL = `
+	CREATE TABLE "versions" (
+	    "instance" CHAR(64) NOT NULL,
+	    "project" CHAR(16) NOT NULL,
+	    "name" CHAR(64) NOT NULL,
+	    "released" BOOLEAN NOT NULL,
+	    "archived" BOOLEAN NOT NULL,
+	    "release_date" DATE,
+	    PRIMARY KEY (instance, project, name)
+	)
+`
+
+type Version struct {
+	Instance    string
+	Project     string
+	Name        string
+	Released    bool
+	Archived    bool
+	ReleaseDate sql.NullTime
+}
+
+// UpsertProjectVersions replaces the versions of a project with those from jira
+func (cache Cache) UpsertProjectVersions(instance, project string, versions []*models.VersionScheme) error {
+	if _, err := cache.DB.Exec(`DELETE FROM versions WHERE instance = ? AND project = ?`, instance, project); err != nil {
+		return fmt.Errorf("failed to clear versions for project %s: %w", project, err)
+	}
+
+	for _, v := range versions {
+		var releaseDate sql.NullTime
+		if v.ReleaseDate != "" {
+			d, err := time.Parse("2006-01-02", v.ReleaseDate)
+			if err != nil {
+				return fmt.Errorf("failed to parse release date %s of %s %s: %w", v.ReleaseDate, project, v.Name, err)
+			}
+			releaseDate = sql.NullTime{Time: d, Valid: true}
+		}
+
+		_, err := cache.DB.Exec(`
+			INSERT OR REPLACE INTO versions (instance, project, name, released, archived, release_date)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, instance, project, v.Name, v.Released, v.Archived, releaseDate)
+		if err != nil {
+			return fmt.Errorf("failed to insert version %s of %s: %w", v.Name, project, err)
+		}
+	}
+
+	return nil
+}
+
+// GetVersions returns the versions of the cache's instance ordered by release date, unscheduled ones last
+func (cache Cache) GetVersions() ([]Version, error) {
+	instanceClause := "1=1"
+	if cache.Instance != "" {
+		instanceClause = "instance = " + sqlString(cache.Instance)
+	}
+
+	rows, err := cache.DB.Query(fmt.Sprintf(`
+		SELECT instance, project, name, released, archived, release_date
+		FROM versions
+		WHERE %s
+		ORDER BY release_date IS NULL, release_date, project, name
+	`, instanceClause))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query versions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var versions []Version
+	for rows.Next() {
+		v := Version{}
+		if err = rows.Scan(&v.Instance, &v.Project, &v.Name, &v.Released, &v.Archived, &v.ReleaseDate); err != nil {
+			return nil, fmt.Errorf("failed to scan versions: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating versions: %w", err)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,82 @@
+package j
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+const ChangelogPageSize = 100
+
+// changelogPage is the response from /rest/api/3/issue/{key}/changelog
+type changelogPage struct {
+	StartAt    int                                   `json:"startAt"`
+	MaxResults int                                   `json:"maxResults"`
+	Total      int                                   `json:"total"`
+	IsLast     bool                                  `json:"isLast"`
+	Values     []*models.IssueChangelogHistoryScheme `json:"values"`
+}
+
+// GetIssueChangelog returns the complete changelog of an issue, the search api only includes the most recent
+// histories so issues with a long history need this to get all their events
+func (i Instance) GetIssueChangelog(key string) ([]*models.IssueChangelogHistoryScheme, error) {
+	deployment, err := i.ResolveDeployment()
+	if err != nil {
+		return nil, err
+	}
+
+	return i.getIssueChangelog(deployment, key)
+}
+
+// getIssueChangelog pages through /rest/api/3/issue/{key}/changelog on cloud, server and data center don't have it but
+// include the whole changelog with the issue when it is expanded
+func (i Instance) getIssueChangelog(deployment, key string) ([]*models.IssueChangelogHistoryScheme, error) {
+	if deployment == DeploymentServer {
+		var issue models.IssueScheme
+		if err := i.get(deployment, fmt.Sprintf("/rest/api/2/issue/%s?expand=changelog&fields=summary", url.PathEscape(key)), &issue); err != nil {
+			return nil, fmt.Errorf("getting changelog of %s: %w", key, err)
+		}
+		if issue.Changelog == nil {
+			return nil, nil
+		}
+		return issue.Changelog.Histories, nil
+	}
+
+	var histories []*models.IssueChangelogHistoryScheme
+
+	startAt := 0
+	for {
+		var page changelogPage
+		if err := i.get(deployment, fmt.Sprintf("/rest/api/3/issue/%s/changelog?startAt=%d&maxResults=%d", url.PathEscape(key), startAt, ChangelogPageSize), &page); err != nil {
+			return nil, fmt.Errorf("getting changelog of %s: %w", key, err)
+		}
+
+		histories = append(histories, page.Values...)
+		startAt += len(page.Values)
+		if page.IsLast || len(page.Values) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+
+	return histories, nil
+}
+
+// completeChangelog replaces a truncated changelog from search with the full one
+func (i Instance) completeChangelog(deployment string, issue *models.IssueScheme) error {
+	if issue.Changelog == nil || issue.Changelog.Total <= len(issue.Changelog.Histories) {
+		return nil
+	}
+
+	histories, err := i.getIssueChangelog(deployment, issue.Key)
+	if err != nil {
+		return err
+	}
+
+	issue.Changelog.Histories = histories
+	issue.Changelog.StartAt = 0
+	issue.Changelog.MaxResults = len(histories)
+	issue.Changelog.Total = len(histories)
+
+	return nil
+}
@@ -2,24 +2,73 @@ package j
 
 import (
 	"context"
+	"net/http"
+	"strings"
+	"sync"
 
 	jira "github.com/ctreminiom/go-atlassian/jira/v3"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/katbyte/gogo-jira-stats/lib/clog"
+	"golang.org/x/oauth2"
+)
+
+// the jira deployments we know how to talk to, auto detects it from /rest/api/2/serverInfo
+const (
+	DeploymentAuto   = "auto"
+	DeploymentCloud  = "cloud"
+	DeploymentServer = "server" // server and data center
 )
 
 type Instance struct {
-	URL   string
-	User  string
-	Token string
+	Name       string
+	URL        string
+	User       string
+	Token      string
+	Deployment string // one of the Deployment constants, empty is auto
+
+	// set for oauth, requests go to APIURL with a bearer token from TokenSource instead of basic auth to URL
+	APIURL      string
+	TokenSource oauth2.TokenSource
+
+	// Client is used for all requests when set, such as to record or replay fixtures
+	Client *http.Client
+
+	// EpicLinkField is the id of the classic Epic Link custom field such as customfield_10014, fetched as the parent of
+	// issues without one
+	EpicLinkField string
+
+	// SprintField is the id of the sprint custom field such as customfield_10020
+	SprintField string
+
+	// NumberFields are numeric custom fields such as story points fetched with each issue
+	NumberFields []NumberField
+
+	// detected is shared by copies of the instance so an auto deployment is only asked for once
+	detected *detectedDeployment
+}
+
+type detectedDeployment struct {
+	sync.Mutex
+	deployment string
 }
 
 func NewInstance(url, user, token string) Instance {
 	return Instance{
+		Name:  "default",
 		URL:   url,
 		User:  user,
 		Token: token,
+
+		detected: &detectedDeployment{},
 	}
 }
 
+func NewNamedInstance(name, url, user, token string) Instance {
+	i := NewInstance(url, user, token)
+	i.Name = name
+	return i
+}
+
 func (i Instance) NewClient() (*jira.Client, context.Context, error) {
 	ctx := context.Background()
 
@@ -33,6 +82,52 @@ func (i Instance) NewClient() (*jira.Client, context.Context, error) {
 	return client, ctx, nil
 }
 
+// defaultClient retries rate limited (429) and failed (5xx) requests, waiting as long as jira's Retry-After asks
+var defaultClient = func() *http.Client {
+	c := retryablehttp.NewClient()
+	c.RetryMax = 7
+	c.Logger = clog.Log
+	return c.StandardClient()
+}()
+
+func (i Instance) httpClient() *http.Client {
+	if i.Client != nil {
+		return i.Client
+	}
+	return defaultClient
+}
+
+// apiURL is the base url for rest api requests
+func (i Instance) apiURL() string {
+	if i.APIURL != "" {
+		return strings.TrimRight(i.APIURL, "/")
+	}
+	return strings.TrimRight(i.URL, "/")
+}
+
+// authorize adds credentials to a request, cloud uses basic auth with an api token while server and data center use a
+// personal access token as a bearer token unless a user is set. oauth tokens are always bearer tokens
+func (i Instance) authorize(req *http.Request, deployment string) error {
+	if i.TokenSource != nil {
+		t, err := i.TokenSource.Token()
+		if err != nil {
+			return err
+		}
+		t.SetAuthHeader(req)
+		return nil
+	}
+
+	if deployment == DeploymentServer && i.User == "" {
+		if i.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+i.Token)
+		}
+		return nil
+	}
+
+	req.SetBasicAuth(i.User, i.Token)
+	return nil
+}
+
 /*
 func (t Token) NewClient() (*github.Client, context.Context) {
 	ctx := context.Background()
@@ -0,0 +1,262 @@
+package j
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// ExportDateFormats are the layouts csv exports use depending on the instance's look and feel settings and the
+// exporting user's language, the first is jira's default. numeric day first dates are european, 01/02/2006 is never
+// read as january
+var ExportDateFormats = []string{
+	"02/Jan/06 3:04 PM",
+	"02/Jan/06 15:04",
+	"02/Jan/2006 3:04 PM",
+	"02/Jan/2006 15:04",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04",
+	"02.01.2006 15:04",
+	"02.01.06 15:04",
+	"02/01/2006 15:04",
+	"02/01/06 15:04",
+	JiraTimeFormat,
+	time.RFC3339,
+}
+
+// ParseExportJSON reads a json export or saved search response, either {"issues": [...]}, a list of issues or a single
+// issue, in the v2 or v3 representation. any changelog included becomes the issue's history
+func ParseExportJSON(r io.Reader) ([]*models.IssueScheme, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading json export: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+
+	var raws []json.RawMessage
+	switch {
+	case bytes.HasPrefix(body, []byte("[")):
+		if err := json.Unmarshal(body, &raws); err != nil {
+			return nil, fmt.Errorf("parsing json export: %w", err)
+		}
+	case bytes.HasPrefix(body, []byte("{")):
+		var search struct {
+			Issues []json.RawMessage `json:"issues"`
+		}
+		if err := json.Unmarshal(body, &search); err != nil {
+			return nil, fmt.Errorf("parsing json export: %w", err)
+		}
+		raws = search.Issues
+		if raws == nil {
+			raws = []json.RawMessage{body}
+		}
+	default:
+		return nil, errors.New("json export is not an object or list of issues")
+	}
+
+	issues := make([]*models.IssueScheme, 0, len(raws))
+	for _, raw := range raws {
+		issue, err := decodeV2Issue(raw)
+		if err != nil {
+			return nil, err
+		}
+		if issue.Key == "" {
+			return nil, errors.New("json export has an issue without a key")
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+// ParseExportCSV reads a csv export from the issue navigator, columns are matched by their header so any export with
+// at least Issue key, Summary, Status and Created works. multi value fields such as Labels and Component/s are
+// repeated columns. csv exports have no history, only the current state of each issue
+func ParseExportCSV(r io.Reader, baseURL string) ([]*models.IssueScheme, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv export header: %w", err)
+	}
+
+	columns := map[string][]int{}
+	for n, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		columns[h] = append(columns[h], n)
+	}
+	for _, required := range []string{"issue key", "summary", "status", "created"} {
+		if len(columns[required]) == 0 {
+			return nil, fmt.Errorf("csv export has no %q column", required)
+		}
+	}
+
+	var issues []*models.IssueScheme
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv export: %w", err)
+		}
+
+		// get is the first value of a column, getAll every non empty value of a repeated one such as labels
+		get := func(name string) string {
+			for _, n := range columns[name] {
+				if n < len(record) && record[n] != "" {
+					return strings.TrimSpace(record[n])
+				}
+			}
+			return ""
+		}
+		getAll := func(name string) []string {
+			var values []string
+			for _, n := range columns[name] {
+				if n < len(record) && strings.TrimSpace(record[n]) != "" {
+					values = append(values, strings.TrimSpace(record[n]))
+				}
+			}
+			return values
+		}
+
+		key := get("issue key")
+		if key == "" {
+			return nil, fmt.Errorf("csv export line %d has no issue key", line)
+		}
+
+		if get("created") == "" {
+			return nil, fmt.Errorf("csv export %s has no created date", key)
+		}
+		created, err := parseExportDate(get("created"))
+		if err != nil {
+			return nil, fmt.Errorf("csv export %s created: %w", key, err)
+		}
+		updated := created
+		if u := get("updated"); u != "" {
+			if updated, err = parseExportDate(u); err != nil {
+				return nil, fmt.Errorf("csv export %s updated: %w", key, err)
+			}
+		}
+
+		id := get("issue id")
+		if id == "" {
+			id = key
+		}
+
+		self := ""
+		if baseURL != "" {
+			self = strings.TrimRight(baseURL, "/") + "/rest/api/2/issue/" + id
+		}
+
+		issue := &models.IssueScheme{
+			ID:   id,
+			Key:  key,
+			Self: self,
+			Fields: &models.IssueFieldsScheme{
+				Summary:   get("summary"),
+				Status:    &models.StatusScheme{Name: get("status")},
+				IssueType: &models.IssueTypeScheme{Name: get("issue type")},
+				Labels:    getAll("labels"),
+				Created:   created.Format(JiraTimeFormat),
+				Updated:   updated.Format(JiraTimeFormat),
+			},
+		}
+
+		if res := get("resolution"); res != "" && !strings.EqualFold(res, "Unresolved") {
+			issue.Fields.Resolution = &models.ResolutionScheme{Name: res}
+		}
+
+		if assignee := get("assignee"); assignee != "" {
+			issue.Fields.Assignee = &models.UserScheme{DisplayName: assignee}
+		}
+		if reporter := get("reporter"); reporter != "" {
+			issue.Fields.Reporter = &models.UserScheme{DisplayName: reporter}
+		}
+		if priority := get("priority"); priority != "" {
+			issue.Fields.Priority = &models.PriorityScheme{Name: priority}
+		}
+
+		for _, name := range getAll("component/s") {
+			issue.Fields.Components = append(issue.Fields.Components, &models.ComponentScheme{Name: name})
+		}
+		for _, name := range getAll("fix version/s") {
+			issue.Fields.FixVersions = append(issue.Fields.FixVersions, &models.VersionScheme{Name: name})
+		}
+		for _, name := range getAll("affects version/s") {
+			issue.Fields.Versions = append(issue.Fields.Versions, &models.VersionScheme{Name: name})
+		}
+
+		// the parent column is the parent's id, newer exports add its key and older ones have the classic epic link
+		parent := get("parent key")
+		if parent == "" {
+			parent = get("custom field (epic link)")
+		}
+		if parent != "" {
+			issue.Fields.Parent = &models.ParentScheme{Key: parent}
+		}
+
+		// links are a column per type and direction, such as "Inward issue link (Blocks)", holding the linked keys.
+		// the export doesn't say how the type describes each direction so only blocks gets jira's default wording
+		for name := range columns {
+			for _, direction := range []string{"inward", "outward"} {
+				linkType, ok := strings.CutPrefix(name, direction+" issue link (")
+				if !ok {
+					continue
+				}
+				linkType = strings.TrimSuffix(linkType, ")")
+				for _, linked := range getAll(name) {
+					issue.Fields.IssueLinks = append(issue.Fields.IssueLinks, exportLink(linkType, direction, linked))
+				}
+			}
+		}
+
+		creator := get("creator")
+		if creator == "" {
+			creator = get("reporter")
+		}
+		if creator != "" {
+			issue.Fields.Creator = &models.UserScheme{DisplayName: creator}
+		}
+
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+// exportLink is a link from a csv export column, with only the linked issue's key
+func exportLink(linkType, direction, linked string) *models.IssueLinkScheme {
+	t := &models.LinkTypeScheme{Name: linkType, Inward: linkType, Outward: linkType}
+	if strings.EqualFold(linkType, "blocks") {
+		t = &models.LinkTypeScheme{Name: "Blocks", Inward: "is blocked by", Outward: "blocks"}
+	}
+
+	l := &models.IssueLinkScheme{Type: t}
+	if direction == "inward" {
+		l.InwardIssue = &models.LinkedIssueScheme{Key: linked}
+	} else {
+		l.OutwardIssue = &models.LinkedIssueScheme{Key: linked}
+	}
+	return l
+}
+
+func parseExportDate(s string) (time.Time, error) {
+	for _, layout := range ExportDateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date %q", s)
+}
@@ -0,0 +1,230 @@
+package j_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+)
+
+func names[T any](values []T, name func(T) string) []string {
+	result := []string{}
+	for _, v := range values {
+		result = append(result, name(v))
+	}
+	return result
+}
+
+func componentNames(i *models.IssueScheme) []string {
+	return names(i.Fields.Components, func(c *models.ComponentScheme) string { return c.Name })
+}
+
+func versionNames(versions []*models.VersionScheme) []string {
+	return names(versions, func(v *models.VersionScheme) string { return v.Name })
+}
+
+func expectStrings(t *testing.T, what string, expected, got []string) {
+	t.Helper()
+	if strings.Join(expected, "|") != strings.Join(got, "|") {
+		t.Errorf("expected %s %q, got %q", what, expected, got)
+	}
+}
+
+func expectTime(t *testing.T, what, expected, got string) {
+	t.Helper()
+	e, err := time.Parse(j.JiraTimeFormat, expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := time.Parse(j.JiraTimeFormat, got)
+	if err != nil {
+		t.Errorf("expected %s to be a jira time, got %q", what, got)
+		return
+	}
+	if !e.Equal(g) {
+		t.Errorf("expected %s %s, got %s", what, expected, got)
+	}
+}
+
+func TestParseExportCSV(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	issues, err := j.ParseExportCSV(f, "https://example.atlassian.net/")
+	if err != nil {
+		t.Fatalf("parsing csv export: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(issues))
+	}
+
+	bug := issues[0]
+	if bug.Key != "AB-12" || bug.ID != "10012" || bug.Self != "https://example.atlassian.net/rest/api/2/issue/10012" {
+		t.Errorf("unexpected AB-12 %+v", bug)
+	}
+	if bug.Fields.Summary != "Fix login redirect" || bug.Fields.Status.Name != "Done" || bug.Fields.IssueType.Name != "Bug" {
+		t.Errorf("unexpected AB-12 fields %+v", bug.Fields)
+	}
+	if bug.Fields.Resolution == nil || bug.Fields.Resolution.Name != "Done" || bug.Fields.Priority.Name != "High" {
+		t.Errorf("expected AB-12 to be resolved as done with high priority, got %+v", bug.Fields)
+	}
+	if bug.Fields.Assignee.DisplayName != "Jane Doe" || bug.Fields.Reporter.DisplayName != "John Roe" || bug.Fields.Creator.DisplayName != "John Roe" {
+		t.Errorf("unexpected AB-12 people %+v", bug.Fields)
+	}
+	expectTime(t, "AB-12 created", "2024-01-02T09:00:00.000+0000", bug.Fields.Created)
+	expectTime(t, "AB-12 updated", "2024-01-05T16:30:00.000+0000", bug.Fields.Updated)
+
+	// repeated columns
+	expectStrings(t, "AB-12 labels", []string{"auth", "regression"}, bug.Fields.Labels)
+	expectStrings(t, "AB-12 components", []string{"api", "web"}, componentNames(bug))
+	expectStrings(t, "AB-12 fix versions", []string{"1.1", "1.2"}, versionNames(bug.Fields.FixVersions))
+	expectStrings(t, "AB-12 affects versions", []string{"1.0"}, versionNames(bug.Fields.Versions))
+
+	if bug.Fields.Parent == nil || bug.Fields.Parent.Key != "AB-1" {
+		t.Errorf("expected the epic link to be AB-12's parent, got %+v", bug.Fields.Parent)
+	}
+	if len(bug.Fields.IssueLinks) != 1 || bug.Fields.IssueLinks[0].OutwardIssue == nil || bug.Fields.IssueLinks[0].OutwardIssue.Key != "AB-13" || bug.Fields.IssueLinks[0].Type.Outward != "blocks" {
+		t.Errorf("expected AB-12 to block AB-13, got %+v", bug.Fields.IssueLinks)
+	}
+
+	// quoted multi line summary, unresolved and the empty dates and columns
+	story := issues[1]
+	if story.Fields.Summary != "Add \"dark\" mode, for the\nsettings page" {
+		t.Errorf("unexpected AB-13 summary %q", story.Fields.Summary)
+	}
+	if story.Fields.Resolution != nil || story.Fields.Assignee != nil || story.Fields.Parent != nil {
+		t.Errorf("expected AB-13 to be unresolved, unassigned and without a parent, got %+v", story.Fields)
+	}
+	if story.Fields.Creator == nil || story.Fields.Creator.DisplayName != "John Roe" {
+		t.Errorf("expected AB-13's creator to fall back to its reporter, got %+v", story.Fields.Creator)
+	}
+	expectTime(t, "AB-13 created", "2024-01-03T11:15:00.000+0000", story.Fields.Created)
+	expectTime(t, "AB-13 updated", "2024-01-03T11:15:00.000+0000", story.Fields.Updated)
+	expectStrings(t, "AB-13 labels", []string{"ui"}, story.Fields.Labels)
+	expectStrings(t, "AB-13 components", []string{"web"}, componentNames(story))
+	expectStrings(t, "AB-13 fix versions", []string{}, versionNames(story.Fields.FixVersions))
+	if len(story.Fields.IssueLinks) != 1 || story.Fields.IssueLinks[0].InwardIssue == nil || story.Fields.IssueLinks[0].InwardIssue.Key != "AB-12" {
+		t.Errorf("expected AB-13 to be related to AB-12, got %+v", story.Fields.IssueLinks)
+	}
+}
+
+func TestParseExportCSVDates(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		created, updated string
+		expected         string // empty when the export is invalid
+	}{
+		{"02/Jan/24 9:00 AM", "", "2024-01-02T09:00:00.000+0000"},
+		{"02/Jan/24 9:00 PM", "", "2024-01-02T21:00:00.000+0000"},
+		{"02/Jan/2024 21:00", "", "2024-01-02T21:00:00.000+0000"},
+		{"2024-01-02 21:00", "", "2024-01-02T21:00:00.000+0000"},
+		{"2024/01/02 21:00", "", "2024-01-02T21:00:00.000+0000"},
+		{"02.01.2024 21:00", "", "2024-01-02T21:00:00.000+0000"},
+		{"02.01.24 21:00", "", "2024-01-02T21:00:00.000+0000"},
+		{"02/01/2024 21:00", "", "2024-01-02T21:00:00.000+0000"},
+		{"2024-01-02T21:00:00.000+0100", "", "2024-01-02T21:00:00.000+0100"},
+		{"", "02/Jan/24 9:00 AM", ""},
+		{"02/Jan/24 9:00 AM", "not a date", ""},
+		{"the second of january", "", ""},
+	}
+
+	for _, tc := range cases {
+		export := "Issue key,Summary,Status,Created,Updated\nAB-1,summary,To Do," + tc.created + "," + tc.updated + "\n"
+		issues, err := j.ParseExportCSV(strings.NewReader(export), "")
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("expected created %q updated %q to be invalid", tc.created, tc.updated)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("created %q: %v", tc.created, err)
+			continue
+		}
+		expectTime(t, tc.created, tc.expected, issues[0].Fields.Created)
+		expectTime(t, "empty updated", tc.expected, issues[0].Fields.Updated)
+	}
+}
+
+func TestParseExportCSVRequiredColumns(t *testing.T) {
+	t.Parallel()
+
+	for _, export := range []string{
+		"Summary,Status,Created\nsummary,To Do,02/Jan/24 9:00 AM\n",
+		"Issue key,Summary,Status,Created\n,summary,To Do,02/Jan/24 9:00 AM\n",
+		"",
+	} {
+		if _, err := j.ParseExportCSV(strings.NewReader(export), ""); err == nil {
+			t.Errorf("expected %q to be invalid", export)
+		}
+	}
+
+	// the header may start with a byte order mark and be in any case
+	issues, err := j.ParseExportCSV(strings.NewReader("\ufeffISSUE KEY,summary,STATUS,created\nAB-1,summary,To Do,02/Jan/24 9:00 AM\n"), "")
+	if err != nil || len(issues) != 1 || issues[0].Key != "AB-1" {
+		t.Errorf("expected AB-1, got %v: %v", issues, err)
+	}
+}
+
+func TestParseExportJSON(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/export.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	issues, err := j.ParseExportJSON(f)
+	if err != nil {
+		t.Fatalf("parsing json export: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(issues))
+	}
+
+	bug := issues[0]
+	if bug.Key != "AB-12" || bug.Fields.Status.Name != "Done" || bug.Fields.Resolution == nil || bug.Fields.Assignee.DisplayName != "Jane Doe" {
+		t.Errorf("unexpected AB-12 %+v", bug.Fields)
+	}
+	expectTime(t, "AB-12 created", "2024-01-02T09:00:00.000+0000", bug.Fields.Created)
+	expectStrings(t, "AB-12 labels", []string{"auth", "regression"}, bug.Fields.Labels)
+	expectStrings(t, "AB-12 components", []string{"api", "web"}, componentNames(bug))
+	expectStrings(t, "AB-12 fix versions", []string{"1.1", "1.2"}, versionNames(bug.Fields.FixVersions))
+	expectStrings(t, "AB-12 affects versions", []string{"1.0"}, versionNames(bug.Fields.Versions))
+	if len(bug.Fields.IssueLinks) != 1 || bug.Fields.IssueLinks[0].OutwardIssue.Key != "AB-13" {
+		t.Errorf("expected AB-12 to block AB-13, got %+v", bug.Fields.IssueLinks)
+	}
+	if bug.Changelog == nil || len(bug.Changelog.Histories) != 1 || bug.Changelog.Histories[0].ID != "20001" || bug.Changelog.Histories[0].Items[0].ToString != "Done" {
+		t.Errorf("expected AB-12's changelog to be its history, got %+v", bug.Changelog)
+	}
+
+	// null and empty values
+	story := issues[1]
+	if story.Fields.Resolution != nil || story.Fields.Assignee != nil || len(story.Fields.Labels) != 0 || len(story.Fields.Components) != 0 || story.Changelog != nil {
+		t.Errorf("expected AB-13 to be unresolved, unassigned, unlabelled and without history, got %+v", story.Fields)
+	}
+
+	// a list of issues and a single issue
+	for _, export := range []string{`[{"key": "AB-1", "fields": {"summary": "one"}}]`, `{"key": "AB-1", "fields": {"summary": "one"}}`} {
+		issues, err := j.ParseExportJSON(strings.NewReader(export))
+		if err != nil || len(issues) != 1 || issues[0].Key != "AB-1" || issues[0].Fields.Summary != "one" {
+			t.Errorf("expected %s to be AB-1, got %v: %v", export, issues, err)
+		}
+	}
+
+	for _, export := range []string{`"AB-1"`, `[{"fields": {}}]`, `{"issues": [`} {
+		if _, err := j.ParseExportJSON(strings.NewReader(export)); err == nil {
+			t.Errorf("expected %s to be invalid", export)
+		}
+	}
+}
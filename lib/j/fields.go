@@ -0,0 +1,195 @@
+package j
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// SprintSchema is the custom field type of the sprint field
+const SprintSchema = "com.pyxis.greenhopper.jira:gh-sprint"
+
+// EpicLinkSchema is the custom field type of the classic Epic Link field, team managed projects and newer cloud sites
+// use the parent field instead
+const EpicLinkSchema = "com.pyxis.greenhopper.jira:gh-epic-link"
+
+// StoryPointsSchema is the custom field type of the story point estimate field of team managed projects, classic ones
+// use a plain number field named Story Points
+const StoryPointsSchema = "com.pyxis.greenhopper.jira:jsw-story-points"
+
+// StoryPoints is the name story points are mapped to as a number field
+const StoryPoints = "story_points"
+
+// StoryPointsFieldNames are the names jira gives the story points fields
+var StoryPointsFieldNames = []string{"Story Points", "Story point estimate"}
+
+// NumberField maps a friendly name to a numeric custom field
+type NumberField struct {
+	Name string // such as story_points
+	ID   string // such as customfield_10016
+
+	// FieldName is the field's name, which is what changelogs call it, empty when it couldn't be looked up
+	FieldName string
+}
+
+// GetFields returns every system and custom field of the instance
+func (i Instance) GetFields() ([]*models.IssueFieldScheme, error) {
+	var fields []*models.IssueFieldScheme
+	if err := i.getAPI("/field", &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// FindCustomField returns the id of the first custom field of a type such as EpicLinkSchema, empty if the instance
+// doesn't have one
+func FindCustomField(fields []*models.IssueFieldScheme, schema string) string {
+	for _, f := range fields {
+		if f.Schema != nil && f.Schema.Custom == schema {
+			return f.ID
+		}
+	}
+	return ""
+}
+
+// FindFieldByName returns the id of the first custom field with one of the names, ignoring case, empty if the instance
+// doesn't have one
+func FindFieldByName(fields []*models.IssueFieldScheme, names ...string) string {
+	for _, name := range names {
+		for _, f := range fields {
+			if f.Custom && strings.EqualFold(f.Name, name) {
+				return f.ID
+			}
+		}
+	}
+	return ""
+}
+
+// FieldName returns the name of the field with an id, empty if the instance doesn't have it
+func FieldName(fields []*models.IssueFieldScheme, id string) string {
+	for _, f := range fields {
+		if f.ID == id {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// SampleFields returns the fields of up to n issues matching the jql, every field as search returns it keyed by id
+func (i Instance) SampleFields(jql string, n int) ([]map[string]json.RawMessage, error) {
+	deployment, err := i.ResolveDeployment()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Issues []struct {
+			Fields map[string]json.RawMessage `json:"fields"`
+		} `json:"issues"`
+	}
+	if deployment == DeploymentServer {
+		err = i.post(deployment, "/rest/api/2/search", searchRequest{JQL: jql, MaxResults: n, Fields: []string{"*all"}}, &resp)
+	} else {
+		err = i.post(deployment, "/rest/api/3/search/jql", searchJQLRequest{JQL: jql, MaxResults: n, Fields: []string{"*all"}}, &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]map[string]json.RawMessage, 0, len(resp.Issues))
+	for _, issue := range resp.Issues {
+		samples = append(samples, issue.Fields)
+	}
+	if len(samples) > n {
+		samples = samples[:n]
+	}
+
+	return samples, nil
+}
+
+// searchFields are the fields requested when searching, the cache's plus any custom fields the instance maps
+func (i Instance) searchFields() []string {
+	fields := append([]string{}, issueFields...)
+	for _, f := range []string{i.EpicLinkField, i.SprintField} {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	for _, f := range i.NumberFields {
+		fields = append(fields, f.ID)
+	}
+	return fields
+}
+
+// CustomFields are the values of custom fields the issue model has no place for, keyed by issue key
+type CustomFields map[string]*IssueCustomFields
+
+type IssueCustomFields struct {
+	// Sprints the issue is in, or was in and wasn't completed in, from the sprint field
+	Sprints []*models.SprintDetailScheme
+
+	// Numbers are the values of the number fields the issue has set, keyed by their friendly name
+	Numbers map[string]float64
+}
+
+// customFields reads the custom fields the typed issues can't hold from a search response body. an epic link becomes
+// the issue's parent when it doesn't already have one, so both kinds of epic look the same to the cache
+func (i Instance) customFields(body []byte, issues []*models.IssueScheme) (CustomFields, error) {
+	custom := CustomFields{}
+	if i.EpicLinkField == "" && i.SprintField == "" && len(i.NumberFields) == 0 {
+		return custom, nil
+	}
+
+	var raw struct {
+		Issues []struct {
+			Key    string                     `json:"key"`
+			Fields map[string]json.RawMessage `json:"fields"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse search response custom fields: %w", err)
+	}
+
+	links := map[string]string{}
+	for _, r := range raw.Issues {
+		var epic string
+		if v, ok := r.Fields[i.EpicLinkField]; ok && json.Unmarshal(v, &epic) == nil && epic != "" {
+			links[r.Key] = epic
+		}
+
+		c := &IssueCustomFields{}
+		if v, ok := r.Fields[i.SprintField]; ok {
+			sprints, err := ParseSprintField(v)
+			if err != nil {
+				return nil, fmt.Errorf("issue %s: %w", r.Key, err)
+			}
+			c.Sprints = sprints
+		}
+		for _, f := range i.NumberFields {
+			var v *float64
+			if raw, ok := r.Fields[f.ID]; ok {
+				if err := json.Unmarshal(raw, &v); err != nil {
+					return nil, fmt.Errorf("issue %s field %s is not a number: %w", r.Key, f.ID, err)
+				}
+			}
+			if v != nil {
+				if c.Numbers == nil {
+					c.Numbers = map[string]float64{}
+				}
+				c.Numbers[f.Name] = *v
+			}
+		}
+		custom[r.Key] = c
+	}
+
+	for _, issue := range issues {
+		if epic, ok := links[issue.Key]; ok && issue.Fields != nil && (issue.Fields.Parent == nil || issue.Fields.Parent.Key == "") {
+			issue.Fields.Parent = &models.ParentScheme{Key: epic}
+		}
+	}
+
+	return custom, nil
+}
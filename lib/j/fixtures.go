@@ -0,0 +1,135 @@
+package j
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fixtures are raw jira responses saved to a directory, one file per request named after the endpoint and a hash of
+// the request so paging through the same jql replays the same pages:
+//
+//	rest-api-3-search-jql-1a2b3c4d5e6f.json
+//
+// only the path from /rest/ on is used, so neither the host, a context path, oauth's cloud id or credentials are part of
+// the name and fixtures recorded against one site replay for any url
+
+// FixtureName is the file a request's response is recorded to and replayed from
+func FixtureName(req *http.Request, body []byte) string {
+	path := req.URL.Path
+	if n := strings.Index(path, "/rest/"); n >= 0 {
+		path = path[n:]
+	}
+	uri := path
+	if req.URL.RawQuery != "" {
+		uri += "?" + req.URL.RawQuery
+	}
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + uri + "\n")) //nolint:errcheck
+	h.Write(body)                                  //nolint:errcheck
+
+	endpoint := strings.NewReplacer("/", "-", ".", "-").Replace(strings.Trim(path, "/"))
+	return fmt.Sprintf("%s-%s.json", endpoint, hex.EncodeToString(h.Sum(nil))[:12])
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close() //nolint:errcheck,gosec
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// RecordTransport passes requests on and saves every successful response to Dir
+type RecordTransport struct {
+	Dir  string
+	Next http.RoundTripper
+}
+
+func (t RecordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck,gosec
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating fixture dir %s: %w", t.Dir, err)
+	}
+	path := filepath.Join(t.Dir, FixtureName(req, body))
+	if err := os.WriteFile(path, respBody, 0o644); err != nil { //nolint:gosec
+		return nil, fmt.Errorf("recording fixture %s: %w", path, err)
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport answers requests from the fixtures in Dir without touching the network
+type ReplayTransport struct {
+	Dir string
+}
+
+func (t ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(t.Dir, FixtureName(req, body))
+	respBody, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture for %s %s, record it with --record: %w", req.Method, req.URL.RequestURI(), err)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// Record makes the instance save every response to dir as it fetches
+func (i *Instance) Record(dir string) {
+	i.Client = &http.Client{Transport: RecordTransport{Dir: dir, Next: i.httpClient().Transport}}
+}
+
+// Replay makes the instance answer every request from the fixtures in dir
+func (i *Instance) Replay(dir string) {
+	i.Client = &http.Client{Transport: ReplayTransport{Dir: dir}}
+}
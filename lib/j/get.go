@@ -0,0 +1,88 @@
+package j
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// getAPI gets a rest api path such as /project/AB/versions from /rest/api/3 on cloud or /rest/api/2 on server and
+// decodes the json response into v
+func (i Instance) getAPI(path string, v any) error {
+	deployment, err := i.ResolveDeployment()
+	if err != nil {
+		return err
+	}
+
+	api := "/rest/api/3"
+	if deployment == DeploymentServer {
+		api = "/rest/api/2"
+	}
+
+	return i.get(deployment, api+path, v)
+}
+
+// getAgile gets a jira software path such as /board/1/sprint from /rest/agile/1.0, the same on cloud and server
+func (i Instance) getAgile(path string, v any) error {
+	deployment, err := i.ResolveDeployment()
+	if err != nil {
+		return err
+	}
+
+	return i.get(deployment, "/rest/agile/1.0"+path, v)
+}
+
+func (i Instance) get(deployment, path string, v any) error {
+	return i.do(deployment, http.MethodGet, path, nil, v)
+}
+
+// post sends body as json to a path such as /rest/api/3/search/jql and decodes the json response into v
+func (i Instance) post(deployment, path string, body, v any) error {
+	return i.do(deployment, http.MethodPost, path, body, v)
+}
+
+func (i Instance) do(deployment, method, path string, body, v any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s request: %w", path, err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, i.apiURL()+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := i.authorize(req, deployment); err != nil {
+		return err
+	}
+
+	resp, err := i.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("jira %s request failed: %w", path, err)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck,gosec
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jira %s failed (status %d): %s", path, resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", path, err)
+	}
+
+	return nil
+}
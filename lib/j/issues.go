@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"sort"
-	"strings"
 
 	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 )
@@ -32,8 +31,26 @@ type searchJQLResponse struct {
 	StartAt       int                   `json:"startAt,omitempty"`
 }
 
-// list all issues for a jql with a callback per api request, using the new /rest/api/3/search/jql endpoint
-func (i Instance) ListAllIssues(jql string, fields, expand *[]string, cb func(*models.IssueSearchScheme, *models.ResponseScheme) error) error {
+// the fields the cache needs, requested explicitly as the default set is huge
+var issueFields = []string{"summary", "status", "issuetype", "resolution", "labels", "creator", "assignee", "reporter", "priority", "components", "fixVersions", "versions", "parent", "issuelinks", "created", "updated"}
+
+// list all issues for a jql with a callback per api request with the page's custom field values, using
+// /rest/api/3/search/jql on cloud and /rest/api/2/search on server and data center
+func (i Instance) ListAllIssues(jql string, cb func(*models.IssueSearchScheme, CustomFields) error) error {
+	deployment, err := i.ResolveDeployment()
+	if err != nil {
+		return err
+	}
+
+	if deployment == DeploymentServer {
+		return i.listAllIssuesServer(jql, cb)
+	}
+
+	return i.listAllIssuesCloud(jql, cb)
+}
+
+// listAllIssuesCloud pages through the new /rest/api/3/search/jql endpoint with nextPageToken
+func (i Instance) listAllIssuesCloud(jql string, cb func(*models.IssueSearchScheme, CustomFields) error) error {
 	nextPageToken := ""
 
 	for {
@@ -41,7 +58,7 @@ func (i Instance) ListAllIssues(jql string, fields, expand *[]string, cb func(*m
 			JQL:           jql,
 			MaxResults:    IssuePageSize,
 			Expand:        "changelog",
-			Fields:        []string{"summary", "status", "issuetype", "resolution", "labels", "creator", "created", "updated"},
+			Fields:        i.searchFields(),
 			NextPageToken: nextPageToken,
 		}
 
@@ -50,15 +67,17 @@ func (i Instance) ListAllIssues(jql string, fields, expand *[]string, cb func(*m
 			return fmt.Errorf("failed to marshal search request: %w", err)
 		}
 
-		searchURL := strings.TrimRight(i.URL, "/") + "/rest/api/3/search/jql"
+		searchURL := i.apiURL() + "/rest/api/3/search/jql"
 		req, err := http.NewRequest(http.MethodPost, searchURL, bytes.NewReader(bodyBytes))
 		if err != nil {
 			return fmt.Errorf("failed to create request: %w", err)
 		}
 		req.Header.Set("Content-Type", "application/json")
-		req.SetBasicAuth(i.User, i.Token)
+		if err := i.authorize(req, DeploymentCloud); err != nil {
+			return err
+		}
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := i.httpClient().Do(req)
 		if err != nil {
 			return fmt.Errorf("jira search request failed: %w", err)
 		}
@@ -77,6 +96,16 @@ func (i Instance) ListAllIssues(jql string, fields, expand *[]string, cb func(*m
 		if err := json.Unmarshal(respBody, &searchResp); err != nil {
 			return fmt.Errorf("failed to parse search response: %w", err)
 		}
+		custom, err := i.customFields(respBody, searchResp.Issues)
+		if err != nil {
+			return err
+		}
+		for _, issue := range searchResp.Issues {
+			issue.Self = i.siteSelf(issue.Self)
+			if err := i.completeChangelog(DeploymentCloud, issue); err != nil {
+				return err
+			}
+		}
 
 		// convert to models.IssueSearchScheme for compatibility with callers
 		result := &models.IssueSearchScheme{
@@ -86,7 +115,7 @@ func (i Instance) ListAllIssues(jql string, fields, expand *[]string, cb func(*m
 			Issues:     searchResp.Issues,
 		}
 
-		if err = cb(result, nil); err != nil {
+		if err = cb(result, custom); err != nil {
 			return fmt.Errorf("callback failed for %s @ %s: %w", i.URL, jql, err)
 		}
 
@@ -101,10 +130,10 @@ func (i Instance) ListAllIssues(jql string, fields, expand *[]string, cb func(*m
 }
 
 // make multiple calls to get all issues for jql
-func (i Instance) GetAllIssues(jql string, fields, expand *[]string) (*[]models.IssueScheme, error) {
+func (i Instance) GetAllIssues(jql string) (*[]models.IssueScheme, error) {
 	var allIssues []models.IssueScheme
 
-	err := i.ListAllIssues(jql, fields, expand, func(results *models.IssueSearchScheme, resp *models.ResponseScheme) error {
+	err := i.ListAllIssues(jql, func(results *models.IssueSearchScheme, _ CustomFields) error {
 		for _, i := range results.Issues {
 			allIssues = append(allIssues, *i)
 		}
@@ -0,0 +1,165 @@
+package j_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+	"golang.org/x/oauth2"
+)
+
+var created = time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+
+// listAll returns every issue a jql lists, keyed by issue key
+func listAll(t *testing.T, inst j.Instance, jql string) map[string]*models.IssueScheme {
+	t.Helper()
+
+	issues := map[string]*models.IssueScheme{}
+	err := inst.ListAllIssues(jql, func(results *models.IssueSearchScheme, _ j.CustomFields) error {
+		for _, i := range results.Issues {
+			issues[i.Key] = i
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("listing issues: %v", err)
+	}
+
+	return issues
+}
+
+// countRequests is how many requests were made to a path
+func countRequests(s *jiratest.Server, request string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r == request {
+			n++
+		}
+	}
+	return n
+}
+
+func TestListAllIssuesPages(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.PageSize = 2
+
+	for n := 1; n <= 5; n++ {
+		s.AddIssues(jiratest.NewIssue(fmt.Sprintf("AB-%d", n), "To Do", created))
+	}
+
+	issues := listAll(t, s.Instance(), "project = AB")
+	if len(issues) != 5 {
+		t.Fatalf("expected 5 issues, got %d", len(issues))
+	}
+	for n := 1; n <= 5; n++ {
+		if issues[fmt.Sprintf("AB-%d", n)] == nil {
+			t.Errorf("AB-%d was not listed", n)
+		}
+	}
+
+	if got := countRequests(s, "POST /rest/api/3/search/jql"); got != 3 {
+		t.Errorf("expected 3 search pages, got %d", got)
+	}
+}
+
+func TestListAllIssuesCompletesChangelog(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.ChangelogLimit = 3
+
+	long := jiratest.NewIssue("AB-1", "To Do", created)
+	statuses := []string{"To Do", "In Progress"}
+	for n := 0; n < 10; n++ {
+		long.Transition(created.Add(time.Duration(n+1)*time.Hour), statuses[n%2], statuses[(n+1)%2])
+	}
+	short := jiratest.NewIssue("AB-2", "To Do", created).Transition(created.Add(time.Hour), "To Do", "Done")
+	s.AddIssues(long, short)
+
+	issues := listAll(t, s.Instance(), "project = AB")
+
+	histories := issues["AB-1"].Changelog.Histories
+	if len(histories) != 10 {
+		t.Fatalf("expected the full changelog of 10 histories for AB-1, got %d", len(histories))
+	}
+	if at := histories[0].Created; at != created.Add(time.Hour).Format(j.JiraTimeFormat) {
+		t.Errorf("expected the first history of AB-1, got one created %s", at)
+	}
+	if got := len(issues["AB-2"].Changelog.Histories); got != 1 {
+		t.Errorf("expected 1 history for AB-2, got %d", got)
+	}
+
+	// only the truncated changelog is fetched separately
+	if got := countRequests(s, "GET /rest/api/3/issue/AB-1/changelog"); got != 1 {
+		t.Errorf("expected 1 changelog request for AB-1, got %d", got)
+	}
+	if got := countRequests(s, "GET /rest/api/3/issue/AB-2/changelog"); got != 0 {
+		t.Errorf("expected no changelog requests for AB-2, got %d", got)
+	}
+}
+
+func TestListAllIssuesRetriesRateLimited(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.AddIssues(jiratest.NewIssue("AB-1", "To Do", created))
+	s.RateLimit(2)
+
+	issues := listAll(t, s.Instance(), "project = AB")
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(issues))
+	}
+
+	if got := countRequests(s, "POST /rest/api/3/search/jql"); got != 3 {
+		t.Errorf("expected 2 rate limited searches and 1 that succeeded, got %d searches", got)
+	}
+}
+
+func TestListAllIssuesUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.AddIssues(jiratest.NewIssue("AB-1", "To Do", created))
+
+	inst := s.Instance()
+	inst.Token = "wrong"
+
+	err := inst.ListAllIssues("project = AB", func(*models.IssueSearchScheme, j.CustomFields) error {
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("expected a 401 error, got %v", err)
+	}
+}
+
+func TestListAllIssuesOAuth(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.AddIssues(jiratest.NewIssue("AB-1", "To Do", created))
+
+	// oauth requests go to the api url with a bearer token, issues link back to the site
+	inst := j.NewInstance("https://example.atlassian.net", "", "")
+	inst.Deployment = j.DeploymentCloud
+	inst.APIURL = s.URL
+	inst.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jiratest.Token, TokenType: "Bearer"})
+
+	issues := listAll(t, inst, "project = AB")
+	if issues["AB-1"] == nil {
+		t.Fatalf("expected AB-1 to be listed")
+	}
+	if self := issues["AB-1"].Self; self != "https://example.atlassian.net/rest/api/3/issue/AB-1" {
+		t.Errorf("expected AB-1 to link to the site, got %s", self)
+	}
+}
@@ -0,0 +1,777 @@
+// Package jiratest is a fake jira for tests, an httptest.Server answering the parts of the rest api fetch uses:
+//
+//	GET  /rest/api/2/serverInfo
+//	POST /rest/api/3/search/jql          paged with nextPageToken
+//	POST /rest/api/2/search              paged with startAt, as server and data center do
+//	GET  /rest/api/3/issue/{key}/changelog
+//	GET  /rest/api/2/issue/{key}         with the whole changelog when expanded
+//	GET  /rest/api/{2,3}/status
+//	GET  /rest/api/{2,3}/field
+//	GET  /rest/api/{2,3}/project/{key}/versions
+//	GET  /rest/agile/1.0/board
+//	GET  /rest/agile/1.0/board/{id}/sprint
+//
+// it says it is jira cloud unless DeploymentType is set to Server or DataCenter.
+// it is seeded with issues, statuses and fields from go structs or json, and can truncate changelogs in search results
+// and rate limit requests like the real thing. requests authenticate with basic auth as User and Token, or Token as a
+// bearer token like a personal access or oauth token:
+//
+//	s := jiratest.NewServer()
+//	defer s.Close()
+//	s.AddIssues(jiratest.NewIssue("AB-1", "To Do", created).Transition(at, "To Do", "In Progress"))
+//	s.RateLimit(2)
+//
+//	inst := s.Instance()
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+)
+
+const (
+	DefaultPageSize       = 50
+	DefaultChangelogLimit = 40 // the number of histories jira cloud includes with each issue in search results
+	User                  = "test@example.com"
+	Token                 = "jiratest-token"
+)
+
+// Seed is what the server answers with, also the format of json seed files
+type Seed struct {
+	Issues   []*models.IssueScheme        `json:"issues"`
+	Statuses []*models.StatusDetailScheme `json:"statuses"`
+	Fields   []*models.IssueFieldScheme   `json:"fields"`
+
+	// Versions are keyed by project
+	Versions map[string][]*models.VersionScheme `json:"versions"`
+
+	// Boards are the agile boards, their location's project key is what the board search filters on. Sprints are keyed
+	// by board id
+	Boards  []*models.BoardScheme               `json:"boards"`
+	Sprints map[int][]*models.BoardSprintScheme `json:"sprints"`
+
+	// CustomFields are keyed by issue key then field id, such as an epic link, as the issue model has no custom fields
+	CustomFields map[string]map[string]any `json:"custom_fields"`
+}
+
+type Server struct {
+	*httptest.Server
+
+	// PageSize caps issues per search page whatever maxResults asks for, ChangelogLimit caps the histories included
+	// with each issue in search results so the rest has to come from the changelog endpoint
+	PageSize       int
+	ChangelogLimit int
+
+	// Match decides which issues a jql returns, nil returns them all
+	Match func(jql string, issue *models.IssueScheme) bool
+
+	// DeploymentType is what serverInfo says the server is, Cloud, Server or DataCenter
+	DeploymentType string
+
+	mu          sync.Mutex
+	seed        Seed
+	rateLimited int
+	requests    []string
+}
+
+func NewServer() *Server {
+	s := &Server{
+		PageSize:       DefaultPageSize,
+		ChangelogLimit: DefaultChangelogLimit,
+		DeploymentType: "Cloud",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/serverInfo", s.handleServerInfo)
+	mux.HandleFunc("/rest/api/3/search/jql", s.handleSearch)
+	mux.HandleFunc("/rest/api/2/search", s.handleSearchServer)
+	mux.HandleFunc("/rest/api/3/issue/", s.handleChangelog)
+	mux.HandleFunc("/rest/api/2/issue/", s.handleIssue)
+	for _, api := range []string{"/rest/api/2", "/rest/api/3"} {
+		mux.HandleFunc(api+"/status", s.handleStatuses)
+		mux.HandleFunc(api+"/field", s.handleFields)
+		mux.HandleFunc(api+"/project/", s.handleVersions)
+	}
+	mux.HandleFunc("/rest/agile/1.0/board", s.handleBoards)
+	mux.HandleFunc("/rest/agile/1.0/board/", s.handleBoardSprints)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// NewServerFromJSON starts a server seeded from a json Seed file
+func NewServerFromJSON(path string) (*Server, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading seed %s: %w", path, err)
+	}
+
+	var seed Seed
+	if err := json.Unmarshal(b, &seed); err != nil {
+		return nil, fmt.Errorf("parsing seed %s: %w", path, err)
+	}
+
+	s := NewServer()
+	s.Load(seed)
+	return s, nil
+}
+
+// Instance is a client for the server with its credentials
+func (s *Server) Instance() j.Instance {
+	i := j.NewInstance(s.URL, User, Token)
+	i.Deployment = j.DeploymentCloud
+	return i
+}
+
+// ServerInstance is a client for the server as server and data center, authenticating with Token as a personal access
+// token
+func (s *Server) ServerInstance() j.Instance {
+	i := j.NewInstance(s.URL, "", Token)
+	i.Deployment = j.DeploymentServer
+	return i
+}
+
+// Load replaces everything the server answers with
+func (s *Server) Load(seed Seed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seed = seed
+}
+
+// AddIssues adds issues, their self url is pointed at the server
+func (s *Server) AddIssues(issues ...*Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, i := range issues {
+		i.Self = s.URL + "/rest/api/3/issue/" + i.Key
+		s.seed.Issues = append(s.seed.Issues, i.IssueScheme)
+	}
+}
+
+func (s *Server) AddStatuses(statuses ...*models.StatusDetailScheme) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seed.Statuses = append(s.seed.Statuses, statuses...)
+}
+
+func (s *Server) AddFields(fields ...*models.IssueFieldScheme) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seed.Fields = append(s.seed.Fields, fields...)
+}
+
+func (s *Server) AddVersions(project string, versions ...*models.VersionScheme) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seed.Versions == nil {
+		s.seed.Versions = map[string][]*models.VersionScheme{}
+	}
+	s.seed.Versions[project] = append(s.seed.Versions[project], versions...)
+}
+
+// AddBoard adds an agile board with its sprints
+func (s *Server) AddBoard(board *models.BoardScheme, sprints ...*models.BoardSprintScheme) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seed.Boards = append(s.seed.Boards, board)
+	if s.seed.Sprints == nil {
+		s.seed.Sprints = map[int][]*models.BoardSprintScheme{}
+	}
+	s.seed.Sprints[board.ID] = append(s.seed.Sprints[board.ID], sprints...)
+}
+
+// SetCustomField sets a custom field of an issue returned by search
+func (s *Server) SetCustomField(key, field string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seed.CustomFields == nil {
+		s.seed.CustomFields = map[string]map[string]any{}
+	}
+	if s.seed.CustomFields[key] == nil {
+		s.seed.CustomFields[key] = map[string]any{}
+	}
+	s.seed.CustomFields[key][field] = value
+}
+
+// RateLimit answers the next n requests with a 429 and a one second Retry-After
+func (s *Server) RateLimit(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = n
+}
+
+// Requests returns the method and path of every request made so far, including rate limited ones
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		limited := s.rateLimited > 0
+		if limited {
+			s.rateLimited--
+		}
+		s.mu.Unlock()
+
+		if limited {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		// serverInfo is answered anonymously by jira too
+		if r.URL.Path != "/rest/api/2/serverInfo" && !authorized(r) {
+			writeError(w, http.StatusUnauthorized, "client must be authenticated to access this resource")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized is true for basic auth with User and Token, as cloud api tokens are sent, or Token as a bearer token, as
+// server personal access tokens and oauth access tokens are
+func authorized(r *http.Request) bool {
+	if user, token, ok := r.BasicAuth(); ok {
+		return user == User && token == Token
+	}
+	return r.Header.Get("Authorization") == "Bearer "+Token
+}
+
+func (s *Server) handleServerInfo(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{
+		"baseUrl":        s.URL,
+		"version":        "1001.0.0-SNAPSHOT",
+		"deploymentType": s.DeploymentType,
+	})
+}
+
+type searchRequest struct {
+	JQL           string   `json:"jql"`
+	MaxResults    int      `json:"maxResults"`
+	Fields        []string `json:"fields"`
+	Expand        string   `json:"expand"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+type searchResponse struct {
+	Issues        []any  `json:"issues"`
+	NextPageToken string `json:"nextPageToken,omitempty"`
+	IsLast        bool   `json:"isLast"`
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	// the page token is opaque to clients, here it is just the offset
+	start := 0
+	if req.NextPageToken != "" {
+		n, err := strconv.Atoi(req.NextPageToken)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid nextPageToken")
+			return
+		}
+		start = n
+	}
+
+	size := s.PageSize
+	if req.MaxResults > 0 && req.MaxResults < size {
+		size = req.MaxResults
+	}
+
+	issues, next, err := s.search(req.JQL, start, size, strings.Contains(req.Expand, "changelog"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := searchResponse{Issues: issues, IsLast: next == 0}
+	if next > 0 {
+		resp.NextPageToken = strconv.Itoa(next)
+	}
+	writeJSON(w, resp)
+}
+
+type searchServerRequest struct {
+	JQL        string   `json:"jql"`
+	StartAt    int      `json:"startAt"`
+	MaxResults int      `json:"maxResults"`
+	Fields     []string `json:"fields"`
+	Expand     []string `json:"expand"`
+}
+
+type searchServerResponse struct {
+	StartAt    int   `json:"startAt"`
+	MaxResults int   `json:"maxResults"`
+	Total      int   `json:"total"`
+	Issues     []any `json:"issues"`
+}
+
+// handleSearchServer is search as server and data center do it, paged by startAt with a total
+func (s *Server) handleSearchServer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req searchServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request payload: "+err.Error())
+		return
+	}
+
+	size := s.PageSize
+	if req.MaxResults > 0 && req.MaxResults < size {
+		size = req.MaxResults
+	}
+	start := max(req.StartAt, 0)
+
+	withChangelog := false
+	for _, e := range req.Expand {
+		withChangelog = withChangelog || e == "changelog"
+	}
+
+	issues, _, err := s.search(req.JQL, start, size, withChangelog)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, searchServerResponse{StartAt: start, MaxResults: size, Total: len(s.match(req.JQL)), Issues: issues})
+}
+
+// match is the issues a jql returns
+func (s *Server) match(jql string) []*models.IssueScheme {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*models.IssueScheme
+	for _, i := range s.seed.Issues {
+		if s.Match == nil || s.Match(jql, i) {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+// search is a page of the issues a jql returns as search returns them, with where the next page starts or 0 when this
+// is the last
+func (s *Server) search(jql string, start, size int, withChangelog bool) ([]any, int, error) {
+	matched := s.match(jql)
+
+	s.mu.Lock()
+	custom := s.seed.CustomFields
+	s.mu.Unlock()
+
+	next := start + size
+	end := next
+	if end >= len(matched) {
+		end, next = len(matched), 0
+	}
+
+	issues := []any{}
+	for _, i := range matched[min(start, len(matched)):end] {
+		issue, err := withCustomFields(s.searchIssue(i, withChangelog), custom[i.Key])
+		if err != nil {
+			return nil, 0, err
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, next, nil
+}
+
+// searchIssue is a copy of the issue as search returns it, with only the most recent histories
+func (s *Server) searchIssue(i *models.IssueScheme, withChangelog bool) *models.IssueScheme {
+	c := *i
+	c.Changelog = nil
+
+	if !withChangelog || i.Changelog == nil {
+		return &c
+	}
+
+	histories := i.Changelog.Histories
+	if s.ChangelogLimit > 0 && len(histories) > s.ChangelogLimit {
+		histories = histories[len(histories)-s.ChangelogLimit:]
+	}
+	c.Changelog = &models.IssueChangelogScheme{
+		StartAt:    0,
+		MaxResults: len(histories),
+		Total:      len(i.Changelog.Histories),
+		Histories:  histories,
+	}
+
+	return &c
+}
+
+// withCustomFields adds custom fields to an issue's fields as search returns them
+func withCustomFields(i *models.IssueScheme, custom map[string]any) (any, error) {
+	if len(custom) == 0 {
+		return i, nil
+	}
+
+	b, err := json.Marshal(i)
+	if err != nil {
+		return nil, fmt.Errorf("encoding issue %s: %w", i.Key, err)
+	}
+	var issue map[string]any
+	if err := json.Unmarshal(b, &issue); err != nil {
+		return nil, fmt.Errorf("decoding issue %s: %w", i.Key, err)
+	}
+
+	fields, _ := issue["fields"].(map[string]any)
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	for k, v := range custom {
+		fields[k] = v
+	}
+	issue["fields"] = fields
+
+	return issue, nil
+}
+
+type changelogResponse struct {
+	StartAt    int                                   `json:"startAt"`
+	MaxResults int                                   `json:"maxResults"`
+	Total      int                                   `json:"total"`
+	IsLast     bool                                  `json:"isLast"`
+	Values     []*models.IssueChangelogHistoryScheme `json:"values"`
+}
+
+func (s *Server) handleChangelog(w http.ResponseWriter, r *http.Request) {
+	key, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/rest/api/3/issue/"), "/")
+	if rest != "changelog" || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+	maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+	if maxResults <= 0 || maxResults > 100 {
+		maxResults = 100
+	}
+
+	issue := s.issue(key)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+
+	var histories []*models.IssueChangelogHistoryScheme
+	if issue.Changelog != nil {
+		histories = issue.Changelog.Histories
+	}
+
+	start := min(max(startAt, 0), len(histories))
+	end := min(start+maxResults, len(histories))
+	writeJSON(w, changelogResponse{
+		StartAt:    start,
+		MaxResults: maxResults,
+		Total:      len(histories),
+		IsLast:     end == len(histories),
+		Values:     append([]*models.IssueChangelogHistoryScheme{}, histories[start:end]...),
+	})
+}
+
+// handleIssue gets an issue as server and data center do, with its whole changelog when it is expanded
+func (s *Server) handleIssue(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+	if strings.Contains(key, "/") || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	i := s.issue(key)
+	if i == nil {
+		writeError(w, http.StatusNotFound, "Issue Does Not Exist")
+		return
+	}
+
+	c := *i
+	if !strings.Contains(r.URL.Query().Get("expand"), "changelog") || i.Changelog == nil {
+		c.Changelog = nil
+	} else {
+		c.Changelog = &models.IssueChangelogScheme{
+			StartAt:    0,
+			MaxResults: len(i.Changelog.Histories),
+			Total:      len(i.Changelog.Histories),
+			Histories:  i.Changelog.Histories,
+		}
+	}
+
+	s.mu.Lock()
+	custom := s.seed.CustomFields[key]
+	s.mu.Unlock()
+
+	issue, err := withCustomFields(&c, custom)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, issue)
+}
+
+func (s *Server) issue(key string) *models.IssueScheme {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, i := range s.seed.Issues {
+		if i.Key == key {
+			return i
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleStatuses(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := append([]*models.StatusDetailScheme{}, s.seed.Statuses...)
+	writeJSON(w, statuses)
+}
+
+func (s *Server) handleFields(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := append([]*models.IssueFieldScheme{}, s.seed.Fields...)
+	sort.Slice(fields, func(a, b int) bool {
+		return fields[a].ID < fields[b].ID
+	})
+	writeJSON(w, fields)
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	_, path, _ := strings.Cut(r.URL.Path, "/project/")
+	project, rest, _ := strings.Cut(path, "/")
+	if rest != "versions" || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := append([]*models.VersionScheme{}, s.seed.Versions[project]...)
+	writeJSON(w, versions)
+}
+
+// agilePage is a page of the agile api, which pages with startAt and isLast
+type agilePage struct {
+	MaxResults int  `json:"maxResults"`
+	StartAt    int  `json:"startAt"`
+	IsLast     bool `json:"isLast"`
+	Values     any  `json:"values"`
+}
+
+func (s *Server) handleBoards(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project := r.URL.Query().Get("projectKeyOrId")
+	boardType := r.URL.Query().Get("type")
+	var boards []*models.BoardScheme
+	for _, b := range s.seed.Boards {
+		if project != "" && (b.Location == nil || b.Location.ProjectKey != project) {
+			continue
+		}
+		if boardType != "" && b.Type != boardType {
+			continue
+		}
+		boards = append(boards, b)
+	}
+
+	start, end, last := s.agilePage(r, len(boards))
+	writeJSON(w, agilePage{MaxResults: s.PageSize, StartAt: start, IsLast: last, Values: append([]*models.BoardScheme{}, boards[start:end]...)})
+}
+
+func (s *Server) handleBoardSprints(w http.ResponseWriter, r *http.Request) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/rest/agile/1.0/board/"), "/")
+	board, err := strconv.Atoi(id)
+	if rest != "sprint" || err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found bool
+	for _, b := range s.seed.Boards {
+		if b.ID == board {
+			found = b.Type == "scrum"
+		}
+	}
+	if !found {
+		writeError(w, http.StatusBadRequest, "The board does not support sprints")
+		return
+	}
+
+	sprints := s.seed.Sprints[board]
+	start, end, last := s.agilePage(r, len(sprints))
+	writeJSON(w, agilePage{MaxResults: s.PageSize, StartAt: start, IsLast: last, Values: append([]*models.BoardSprintScheme{}, sprints[start:end]...)})
+}
+
+// agilePage is the range of n values a request's startAt asks for
+func (s *Server) agilePage(r *http.Request, n int) (start, end int, last bool) {
+	start, _ = strconv.Atoi(r.URL.Query().Get("startAt"))
+	start = min(max(start, 0), n)
+	end = min(start+s.PageSize, n)
+	return start, end, end == n
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v) //nolint:errcheck,gosec
+}
+
+// writeError responds in jira's error collection format
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"errorMessages": []string{msg}, "errors": map[string]string{}}) //nolint:errcheck,gosec
+}
+
+// Issue builds a seed issue
+type Issue struct {
+	*models.IssueScheme
+	histories int
+}
+
+// NewIssue is a bug with the status, created and last updated at created
+func NewIssue(key, status string, created time.Time) *Issue {
+	ts := created.Format(j.JiraTimeFormat)
+	return &Issue{
+		IssueScheme: &models.IssueScheme{
+			ID:  key,
+			Key: key,
+			Fields: &models.IssueFieldsScheme{
+				Summary:   "summary of " + key,
+				Status:    &models.StatusScheme{Name: status},
+				IssueType: &models.IssueTypeScheme{Name: "Bug"},
+				Creator:   &models.UserScheme{DisplayName: "Jira Test"},
+				Created:   ts,
+				Updated:   ts,
+			},
+			Changelog: &models.IssueChangelogScheme{},
+		},
+	}
+}
+
+// Transition records a status change at the time and makes it the current status
+func (i *Issue) Transition(at time.Time, from, to string) *Issue {
+	i.Change(at, "status", from, to)
+	i.Fields.Status = &models.StatusScheme{Name: to}
+	return i
+}
+
+// Component adds the issue to a component
+func (i *Issue) Component(name string) *Issue {
+	i.Fields.Components = append(i.Fields.Components, &models.ComponentScheme{Name: name})
+	return i
+}
+
+// FixVersion adds a fix version, AffectedVersion an affected version
+func (i *Issue) FixVersion(name string) *Issue {
+	i.Fields.FixVersions = append(i.Fields.FixVersions, &models.VersionScheme{Name: name})
+	return i
+}
+
+func (i *Issue) AffectedVersion(name string) *Issue {
+	i.Fields.Versions = append(i.Fields.Versions, &models.VersionScheme{Name: name})
+	return i
+}
+
+// Parent sets the issue's parent, its epic or for a sub-task the issue it is part of
+func (i *Issue) Parent(key string) *Issue {
+	i.Fields.Parent = &models.ParentScheme{Key: key}
+	return i
+}
+
+// BlockedBy links the issue as blocked by another, Blocks as blocking another. only this issue gets the link so add the
+// other end to the other issue when both are seeded
+func (i *Issue) BlockedBy(key string) *Issue {
+	i.Fields.IssueLinks = append(i.Fields.IssueLinks, &models.IssueLinkScheme{Type: blocksLinkType(), InwardIssue: &models.LinkedIssueScheme{Key: key}})
+	return i
+}
+
+func (i *Issue) Blocks(key string) *Issue {
+	i.Fields.IssueLinks = append(i.Fields.IssueLinks, &models.IssueLinkScheme{Type: blocksLinkType(), OutwardIssue: &models.LinkedIssueScheme{Key: key}})
+	return i
+}
+
+func blocksLinkType() *models.LinkTypeScheme {
+	return &models.LinkTypeScheme{Name: "Blocks", Inward: "is blocked by", Outward: "blocks"}
+}
+
+// Type sets the issue type, issues are bugs by default
+func (i *Issue) Type(name string) *Issue {
+	i.Fields.IssueType = &models.IssueTypeScheme{Name: name}
+	return i
+}
+
+// Change records a change to any field at the time
+func (i *Issue) Change(at time.Time, field, from, to string) *Issue {
+	return i.change(at, &models.IssueChangelogHistoryItemScheme{Field: field, FromString: from, ToString: to})
+}
+
+// SprintChange records the issue moving between sprints at the time, with their names and ids as jira does
+func (i *Issue) SprintChange(at time.Time, from, to []*models.BoardSprintScheme) *Issue {
+	list := func(sprints []*models.BoardSprintScheme) (names, ids string) {
+		n := make([]string, 0, len(sprints))
+		d := make([]string, 0, len(sprints))
+		for _, s := range sprints {
+			n = append(n, s.Name)
+			d = append(d, strconv.Itoa(s.ID))
+		}
+		return strings.Join(n, ", "), strings.Join(d, ", ")
+	}
+
+	fromNames, fromIDs := list(from)
+	toNames, toIDs := list(to)
+	return i.change(at, &models.IssueChangelogHistoryItemScheme{Field: "Sprint", From: fromIDs, FromString: fromNames, To: toIDs, ToString: toNames})
+}
+
+func (i *Issue) change(at time.Time, item *models.IssueChangelogHistoryItemScheme) *Issue {
+	i.histories++
+	i.Changelog.Histories = append(i.Changelog.Histories, &models.IssueChangelogHistoryScheme{
+		ID:      fmt.Sprintf("%s-%d", i.Key, i.histories),
+		Author:  &models.IssueChangelogAuthor{DisplayName: "Jira Test"},
+		Created: at.Format(j.JiraTimeFormat),
+		Items:   []*models.IssueChangelogHistoryItemScheme{item},
+	})
+	i.Changelog.Total = len(i.Changelog.Histories)
+	i.Changelog.MaxResults = len(i.Changelog.Histories)
+
+	if updated, err := time.Parse(j.JiraTimeFormat, i.Fields.Updated); err != nil || at.After(updated) {
+		i.Fields.Updated = at.Format(j.JiraTimeFormat)
+	}
+	return i
+}
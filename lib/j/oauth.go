@@ -0,0 +1,197 @@
+package j
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// atlassian's oauth 2.0 (3LO) endpoints, api calls with an oauth token go through api.atlassian.com with the cloud id
+// of the site rather than to the site itself
+const (
+	AtlassianAuthURL       = "https://auth.atlassian.com/authorize"
+	AtlassianTokenURL      = "https://auth.atlassian.com/oauth/token"
+	AccessibleResourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+	AtlassianAPIURL        = "https://api.atlassian.com/ex/jira/"
+)
+
+// OAuthScopes are what fetch needs, offline_access gets us a refresh token so daemons keep working unattended
+var OAuthScopes = []string{"read:jira-work", "read:jira-user", "offline_access"}
+
+// OAuthToken is the token as stored on disk along with the site it was granted for
+type OAuthToken struct {
+	oauth2.Token
+	CloudID string `json:"cloud_id"`
+	SiteURL string `json:"site_url"`
+}
+
+// AccessibleResource is a site an oauth token has been granted access to
+type AccessibleResource struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func NewOAuthConfig(clientID, clientSecret, redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       OAuthScopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AtlassianAuthURL,
+			TokenURL:  AtlassianTokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+// OAuthAuthCodeURL is the url to send the user to for consent
+func OAuthAuthCodeURL(conf *oauth2.Config, state string) string {
+	return conf.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("audience", "api.atlassian.com"),
+		oauth2.SetAuthURLParam("prompt", "consent"),
+	)
+}
+
+// GetAccessibleResources lists the sites an access token can be used with
+func GetAccessibleResources(ctx context.Context, token *oauth2.Token) ([]AccessibleResource, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, AccessibleResourcesURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	token.SetAuthHeader(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("accessible resources request failed: %w", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck,gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("accessible resources failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var resources []AccessibleResource
+	if err := json.Unmarshal(body, &resources); err != nil {
+		return nil, fmt.Errorf("failed to parse accessible resources response: %w", err)
+	}
+
+	return resources, nil
+}
+
+func LoadOAuthToken(path string) (*OAuthToken, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading oauth token %s: %w", path, err)
+	}
+
+	var t OAuthToken
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("parsing oauth token %s: %w", path, err)
+	}
+
+	return &t, nil
+}
+
+// SaveOAuthToken writes the token readable only by us, via a rename so a crash never leaves a truncated token behind
+func SaveOAuthToken(path string, t *OAuthToken) error {
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding oauth token: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing oauth token %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close() //nolint:errcheck,gosec
+		return fmt.Errorf("writing oauth token %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing oauth token %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return fmt.Errorf("writing oauth token %s: %w", path, err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// fileTokenSource refreshes the token when it expires and writes it back to disk, atlassian rotates refresh tokens
+// so the new one has to be kept or the next run can't authenticate
+type fileTokenSource struct {
+	mu     sync.Mutex
+	path   string
+	stored OAuthToken
+	src    oauth2.TokenSource
+}
+
+func (s *fileTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.src.Token()
+	if err != nil {
+		return nil, fmt.Errorf("refreshing oauth token %s: %w", s.path, err)
+	}
+
+	if t.AccessToken != s.stored.AccessToken || t.RefreshToken != s.stored.RefreshToken {
+		s.stored.Token = *t
+		if err := SaveOAuthToken(s.path, &s.stored); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// NewOAuthInstance is a cloud instance authenticated with the oauth token stored at path, refreshed as needed
+func NewOAuthInstance(name, path string, conf *oauth2.Config) (*Instance, error) {
+	t, err := LoadOAuthToken(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.CloudID == "" {
+		return nil, fmt.Errorf("oauth token %s has no cloud id, authorise again with the auth command", path)
+	}
+
+	i := NewNamedInstance(name, t.SiteURL, "", "")
+	i.Deployment = DeploymentCloud
+	i.APIURL = AtlassianAPIURL + t.CloudID
+	i.TokenSource = &fileTokenSource{
+		path:   path,
+		stored: *t,
+		src:    conf.TokenSource(context.Background(), &t.Token),
+	}
+
+	return &i, nil
+}
+
+// siteSelf rewrites api.atlassian.com issue urls back onto the site so the cache links to the issue, not the api
+func (i Instance) siteSelf(self string) string {
+	if i.APIURL == "" || !strings.HasPrefix(self, i.APIURL) {
+		return self
+	}
+
+	return strings.TrimRight(i.URL, "/") + strings.TrimPrefix(self, i.APIURL)
+}
@@ -0,0 +1,162 @@
+package j
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// serverInfo is the part of /rest/api/2/serverInfo we care about, deploymentType is Cloud, Server or DataCenter
+type serverInfo struct {
+	BaseURL        string `json:"baseUrl"`
+	Version        string `json:"version"`
+	DeploymentType string `json:"deploymentType"`
+}
+
+// searchRequest is the POST body for the server and data center /rest/api/2/search endpoint
+type searchRequest struct {
+	JQL        string   `json:"jql"`
+	StartAt    int      `json:"startAt"`
+	MaxResults int      `json:"maxResults"`
+	Fields     []string `json:"fields,omitempty"`
+	Expand     []string `json:"expand,omitempty"`
+}
+
+// ResolveDeployment returns the configured deployment, asking the instance the first time when it is auto
+func (i Instance) ResolveDeployment() (string, error) {
+	switch i.Deployment {
+	case DeploymentCloud, DeploymentServer:
+		return i.Deployment, nil
+	case "", DeploymentAuto:
+		if i.detected == nil {
+			return i.DetectDeployment()
+		}
+
+		i.detected.Lock()
+		defer i.detected.Unlock()
+		if i.detected.deployment == "" {
+			deployment, err := i.DetectDeployment()
+			if err != nil {
+				return "", err
+			}
+			i.detected.deployment = deployment
+		}
+		return i.detected.deployment, nil
+	}
+
+	return "", fmt.Errorf("unknown jira deployment %q for %s, expected %s, %s or %s", i.Deployment, i.URL, DeploymentAuto, DeploymentCloud, DeploymentServer)
+}
+
+// DetectDeployment asks /rest/api/2/serverInfo, which both cloud and server answer, what the instance is. older
+// server versions do not include a deployment type so anything not cloud is treated as server
+func (i Instance) DetectDeployment() (string, error) {
+	infoURL := i.apiURL() + "/rest/api/2/serverInfo"
+	req, err := http.NewRequest(http.MethodGet, infoURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := i.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("jira server info request failed: %w", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck,gosec
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("detecting jira deployment of %s failed (status %d), set the deployment explicitly: %s", i.URL, resp.StatusCode, string(body))
+	}
+
+	var info serverInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", fmt.Errorf("failed to parse server info response: %w", err)
+	}
+
+	if strings.EqualFold(info.DeploymentType, "Cloud") {
+		return DeploymentCloud, nil
+	}
+
+	return DeploymentServer, nil
+}
+
+// listAllIssuesServer pages through /rest/api/2/search with startAt, the v2 issues decode into the same scheme as
+// cloud for the fields we request
+func (i Instance) listAllIssuesServer(jql string, cb func(*models.IssueSearchScheme, CustomFields) error) error {
+	startAt := 0
+
+	for {
+		reqBody := searchRequest{
+			JQL:        jql,
+			StartAt:    startAt,
+			MaxResults: IssuePageSize,
+			Fields:     i.searchFields(),
+			Expand:     []string{"changelog"},
+		}
+
+		bodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal search request: %w", err)
+		}
+
+		searchURL := i.apiURL() + "/rest/api/2/search"
+		req, err := http.NewRequest(http.MethodPost, searchURL, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if err := i.authorize(req, DeploymentServer); err != nil {
+			return err
+		}
+
+		resp, err := i.httpClient().Do(req)
+		if err != nil {
+			return fmt.Errorf("jira search request failed: %w", err)
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close() //nolint:errcheck,gosec
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("jira search failed (status %d): %s", resp.StatusCode, string(respBody))
+		}
+
+		var result models.IssueSearchScheme
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return fmt.Errorf("failed to parse search response: %w", err)
+		}
+		custom, err := i.customFields(respBody, result.Issues)
+		if err != nil {
+			return err
+		}
+		for _, issue := range result.Issues {
+			if err := i.completeChangelog(DeploymentServer, issue); err != nil {
+				return err
+			}
+		}
+
+		if err = cb(&result, custom); err != nil {
+			return fmt.Errorf("callback failed for %s @ %s: %w", i.URL, jql, err)
+		}
+
+		// the server may cap maxResults lower than asked so page by what actually came back
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			break
+		}
+	}
+
+	return nil
+}
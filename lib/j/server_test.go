@@ -0,0 +1,90 @@
+package j_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/j"
+	"github.com/katbyte/gogo-jira-stats/lib/j/jiratest"
+)
+
+func TestDetectDeployment(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+
+	for deploymentType, expected := range map[string]string{"Cloud": j.DeploymentCloud, "Server": j.DeploymentServer, "DataCenter": j.DeploymentServer} {
+		s.DeploymentType = deploymentType
+		got, err := j.NewInstance(s.URL, "", jiratest.Token).ResolveDeployment()
+		if err != nil {
+			t.Fatalf("detecting %s: %v", deploymentType, err)
+		}
+		if got != expected {
+			t.Errorf("expected %s to be %s, got %s", deploymentType, expected, got)
+		}
+	}
+}
+
+func TestDetectDeploymentOnce(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.DeploymentType = "Server"
+	s.AddIssues(jiratest.NewIssue("AB-1", "To Do", created))
+
+	// copies of an instance share what was detected
+	inst := j.NewInstance(s.URL, "", jiratest.Token)
+	listAll(t, inst, "project = AB")
+	copied := inst
+	listAll(t, copied, "project = AB")
+
+	if got := countRequests(s, "GET /rest/api/2/serverInfo"); got != 1 {
+		t.Errorf("expected the deployment to be detected once, got %d server info requests", got)
+	}
+	if got := countRequests(s, "POST /rest/api/2/search"); got != 2 {
+		t.Errorf("expected both lists to search server, got %d searches", got)
+	}
+}
+
+func TestListAllIssuesServer(t *testing.T) {
+	t.Parallel()
+
+	s := jiratest.NewServer()
+	defer s.Close()
+	s.DeploymentType = "Server"
+	s.PageSize = 2
+	s.ChangelogLimit = 3
+
+	long := jiratest.NewIssue("AB-1", "To Do", created)
+	statuses := []string{"To Do", "In Progress"}
+	for n := 0; n < 10; n++ {
+		long.Transition(created.Add(time.Duration(n+1)*time.Hour), statuses[n%2], statuses[(n+1)%2])
+	}
+	s.AddIssues(long)
+	for n := 2; n <= 5; n++ {
+		s.AddIssues(jiratest.NewIssue(fmt.Sprintf("AB-%d", n), "To Do", created))
+	}
+
+	// a personal access token is sent as a bearer token
+	issues := listAll(t, s.ServerInstance(), "project = AB")
+	if len(issues) != 5 {
+		t.Fatalf("expected 5 issues, got %d", len(issues))
+	}
+	if got := countRequests(s, "POST /rest/api/2/search"); got != 3 {
+		t.Errorf("expected 3 search pages, got %d", got)
+	}
+
+	// server has no changelog endpoint, the whole changelog comes with the issue
+	if got := len(issues["AB-1"].Changelog.Histories); got != 10 {
+		t.Errorf("expected the full changelog of 10 histories for AB-1, got %d", got)
+	}
+	if got := countRequests(s, "GET /rest/api/2/issue/AB-1"); got != 1 {
+		t.Errorf("expected 1 issue request for AB-1, got %d", got)
+	}
+	if got := countRequests(s, "GET /rest/api/3/issue/AB-1/changelog"); got != 0 {
+		t.Errorf("expected no cloud changelog requests, got %d", got)
+	}
+}
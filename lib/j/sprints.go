@@ -0,0 +1,135 @@
+package j
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// GetScrumBoards returns the scrum boards of a project, kanban boards have no sprints
+func (i Instance) GetScrumBoards(project string) ([]*models.BoardScheme, error) {
+	var boards []*models.BoardScheme
+	for start := 0; ; {
+		var page models.BoardPageScheme
+		path := fmt.Sprintf("/board?type=scrum&projectKeyOrId=%s&startAt=%d", url.QueryEscape(project), start)
+		if err := i.getAgile(path, &page); err != nil {
+			return nil, err
+		}
+
+		boards = append(boards, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return boards, nil
+		}
+		start += len(page.Values)
+	}
+}
+
+// GetBoardSprints returns every sprint of a board, closed, active and future
+func (i Instance) GetBoardSprints(board int) ([]*models.BoardSprintScheme, error) {
+	var sprints []*models.BoardSprintScheme
+	for start := 0; ; {
+		var page models.BoardSprintPageScheme
+		if err := i.getAgile(fmt.Sprintf("/board/%d/sprint?startAt=%d", board, start), &page); err != nil {
+			return nil, err
+		}
+
+		sprints = append(sprints, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return sprints, nil
+		}
+		start += len(page.Values)
+	}
+}
+
+// ParseSprintField reads the sprint field, a list of objects on cloud and of strings on server such as
+// com.atlassian.greenhopper.service.sprint.Sprint@1f[id=1,rapidViewId=2,state=CLOSED,name=Sprint 1,startDate=...]
+func ParseSprintField(raw json.RawMessage) ([]*models.SprintDetailScheme, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) == 0 {
+		return nil, nil //nolint:nilerr // null or not a list, so no sprints
+	}
+
+	sprints := make([]*models.SprintDetailScheme, 0, len(values))
+	for _, v := range values {
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			sprint, err := parseServerSprint(s)
+			if err != nil {
+				return nil, err
+			}
+			sprints = append(sprints, sprint)
+			continue
+		}
+
+		var sprint struct {
+			models.SprintDetailScheme
+			BoardID int `json:"boardId"`
+		}
+		if err := json.Unmarshal(v, &sprint); err != nil {
+			return nil, fmt.Errorf("failed to parse sprint %s: %w", string(v), err)
+		}
+		if sprint.OriginBoardID == 0 {
+			sprint.OriginBoardID = sprint.BoardID
+		}
+		sprints = append(sprints, &sprint.SprintDetailScheme)
+	}
+
+	return sprints, nil
+}
+
+// parseServerSprint reads the key=value pairs between the brackets, splitting only on the keys jira writes as names and
+// goals can have commas in them
+func parseServerSprint(s string) (*models.SprintDetailScheme, error) {
+	start, end := strings.Index(s, "["), strings.LastIndex(s, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse sprint %q", s)
+	}
+	body := "," + s[start+1:end]
+
+	keys := []string{"id", "rapidViewId", "state", "name", "goal", "startDate", "endDate", "completeDate", "activatedDate", "sequence", "autoStartStop", "synced", "incompleteIssuesDestinationId"}
+	type at struct {
+		key   string
+		index int
+	}
+	var found []at
+	for _, k := range keys {
+		if n := strings.Index(body, ","+k+"="); n >= 0 {
+			found = append(found, at{k, n})
+		}
+	}
+	sort.Slice(found, func(a, b int) bool { return found[a].index < found[b].index })
+
+	values := map[string]string{}
+	for n, f := range found {
+		valueEnd := len(body)
+		if n+1 < len(found) {
+			valueEnd = found[n+1].index
+		}
+		v := body[f.index+len(f.key)+2 : valueEnd]
+		if v != "<null>" {
+			values[f.key] = v
+		}
+	}
+
+	id, err := strconv.Atoi(values["id"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sprint id of %q: %w", s, err)
+	}
+	board, _ := strconv.Atoi(values["rapidViewId"])
+
+	return &models.SprintDetailScheme{
+		ID:            id,
+		State:         strings.ToLower(values["state"]),
+		Name:          values["name"],
+		Goal:          values["goal"],
+		StartDate:     values["startDate"],
+		EndDate:       values["endDate"],
+		CompleteDate:  values["completeDate"],
+		OriginBoardID: board,
+	}, nil
+}
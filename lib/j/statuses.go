@@ -0,0 +1,15 @@
+package j
+
+import (
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// GetStatuses returns every workflow status of the instance with its category, To Do, In Progress or Done
+func (i Instance) GetStatuses() ([]*models.StatusDetailScheme, error) {
+	var statuses []*models.StatusDetailScheme
+	if err := i.getAPI("/status", &statuses); err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,17 @@
+package j
+
+import (
+	"net/url"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// GetProjectVersions returns every version of a project including released and archived ones, with their release dates
+func (i Instance) GetProjectVersions(project string) ([]*models.VersionScheme, error) {
+	var versions []*models.VersionScheme
+	if err := i.getAPI("/project/"+url.PathEscape(project)+"/versions", &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,168 @@
+package j
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+const (
+	WebhookIssueCreated = "jira:issue_created"
+	WebhookIssueUpdated = "jira:issue_updated"
+	WebhookIssueDeleted = "jira:issue_deleted"
+)
+
+// JiraTimeFormat is how jira formats timestamps in api responses
+const JiraTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+// webhookPayload is the subset of a jira issue webhook body we use
+type webhookPayload struct {
+	Timestamp    int64                        `json:"timestamp"`
+	WebhookEvent string                       `json:"webhookEvent"`
+	User         *models.IssueChangelogAuthor `json:"user,omitempty"`
+	Issue        json.RawMessage              `json:"issue"`
+	Changelog    *struct {
+		ID    string                                    `json:"id"`
+		Items []*models.IssueChangelogHistoryItemScheme `json:"items"`
+	} `json:"changelog,omitempty"`
+}
+
+type WebhookEvent struct {
+	Event string
+	Issue *models.IssueScheme // with the webhook changelog as its only history
+
+	raw json.RawMessage // the issue as sent, for its custom fields
+}
+
+// ParseWebhook decodes a jira issue webhook into the same issue model the search api returns
+func ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var p webhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+
+	switch p.WebhookEvent {
+	case WebhookIssueCreated, WebhookIssueUpdated, WebhookIssueDeleted:
+	default:
+		return nil, fmt.Errorf("unsupported webhook event %q", p.WebhookEvent)
+	}
+
+	if len(p.Issue) == 0 {
+		return nil, fmt.Errorf("webhook %s has no issue", p.WebhookEvent)
+	}
+
+	issue, err := decodeV2Issue(p.Issue)
+	if err != nil {
+		return nil, err
+	}
+
+	// the payload only has when the webhook was sent, the history id lets the cache replace it with when the change
+	// happened once the issue is fetched
+	if p.Changelog != nil && len(p.Changelog.Items) > 0 {
+		ts := time.Now()
+		if p.Timestamp > 0 {
+			ts = time.UnixMilli(p.Timestamp)
+		}
+
+		issue.Changelog = &models.IssueChangelogScheme{
+			Histories: []*models.IssueChangelogHistoryScheme{{
+				ID:      p.Changelog.ID,
+				Author:  p.User,
+				Created: ts.UTC().Format(JiraTimeFormat),
+				Items:   p.Changelog.Items,
+			}},
+		}
+	}
+
+	return &WebhookEvent{
+		Event: p.WebhookEvent,
+		Issue: issue,
+		raw:   p.Issue,
+	}, nil
+}
+
+// WebhookCustomFields reads the instance's custom fields from a webhook's issue as search does, webhooks send every
+// field so the epic link becomes its parent and its sprints and numbers are there just like in search results
+func (i Instance) WebhookCustomFields(e *WebhookEvent) (*IssueCustomFields, error) {
+	body, err := json.Marshal(map[string][]json.RawMessage{"issues": {e.raw}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encode webhook issue %s: %w", e.Issue.Key, err)
+	}
+
+	custom, err := i.customFields(body, []*models.IssueScheme{e.Issue})
+	if err != nil {
+		return nil, err
+	}
+
+	return custom[e.Issue.Key], nil
+}
+
+// webhooks and exports send issues in the v2 representation where rich text fields are plain strings rather than ADF,
+// we don't use any of them so drop them before decoding into the v3 model
+func decodeV2Issue(raw json.RawMessage) (*models.IssueScheme, error) {
+	var issue struct {
+		ID        string                       `json:"id"`
+		Key       string                       `json:"key"`
+		Self      string                       `json:"self"`
+		Fields    map[string]json.RawMessage   `json:"fields"`
+		Changelog *models.IssueChangelogScheme `json:"changelog,omitempty"`
+	}
+	if err := json.Unmarshal(raw, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse issue: %w", err)
+	}
+
+	for _, f := range []string{"description", "environment", "comment", "worklog"} {
+		delete(issue.Fields, f)
+	}
+
+	fields, err := json.Marshal(issue.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encode issue %s fields: %w", issue.Key, err)
+	}
+
+	result := models.IssueScheme{
+		ID:        issue.ID,
+		Key:       issue.Key,
+		Self:      issue.Self,
+		Fields:    &models.IssueFieldsScheme{},
+		Changelog: issue.Changelog,
+	}
+	if err := json.Unmarshal(fields, result.Fields); err != nil {
+		return nil, fmt.Errorf("failed to parse issue %s fields: %w", issue.Key, err)
+	}
+
+	return &result, nil
+}
+
+// VerifyWebhook checks the X-Hub-Signature (sha256=<hmac hex>) header jira cloud sends for webhooks with a secret,
+// or for jira versions that can't sign payloads a secret passed as a query parameter
+func VerifyWebhook(secret string, body []byte, signatureHeader, querySecret string) bool {
+	if secret == "" {
+		return false
+	}
+
+	if signatureHeader != "" {
+		sig, ok := strings.CutPrefix(signatureHeader, "sha256=")
+		if !ok {
+			return false
+		}
+
+		got, err := hex.DecodeString(sig)
+		if err != nil {
+			return false
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hmac.Equal(got, mac.Sum(nil))
+	}
+
+	return querySecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(querySecret)) == 1
+}
@@ -0,0 +1,306 @@
+// Package parquet is a minimal streaming parquet writer for flat rows of optional strings, numbers and timestamps.
+// rows are buffered into row groups which are written as they fill, one uncompressed PLAIN data page per column, so
+// memory use is bounded by the row group size rather than the number of rows
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const magic = "PAR1"
+
+// DefaultRowGroupSize is the number of rows buffered before they are written out
+const DefaultRowGroupSize = 10000
+
+type Type int
+
+const (
+	String    Type = iota // utf8 byte array, from a string
+	Double                // from a float64
+	Int64                 // from an int or int64
+	Timestamp             // milliseconds since the epoch, from a time.Time
+)
+
+// parquet's physical and converted type ids
+const (
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	pageTypeData      = 0
+)
+
+// Column is a column of the schema, every column is optional so any value can be nil
+type Column struct {
+	Name string
+	Type Type
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type Writer struct {
+	RowGroupSize int
+
+	w       io.Writer
+	written int64
+	columns []Column
+	rows    [][]any
+
+	numRows   int64
+	rowGroups [][]columnChunk
+	err       error
+}
+
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		RowGroupSize: DefaultRowGroupSize,
+		w:            w,
+		columns:      columns,
+	}
+}
+
+func (w *Writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.written += int64(n)
+	w.err = err
+}
+
+// Write adds a row, its values in column order
+func (w *Writer) Write(row []any) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(row), len(w.columns))
+	}
+
+	for n, v := range row {
+		if err := checkValue(w.columns[n], v); err != nil {
+			return err
+		}
+	}
+
+	w.rows = append(w.rows, row)
+	if len(w.rows) >= w.RowGroupSize {
+		return w.flush()
+	}
+
+	return w.err
+}
+
+func checkValue(c Column, v any) error {
+	ok := false
+	switch v.(type) {
+	case nil:
+		ok = true
+	case string:
+		ok = c.Type == String
+	case float64:
+		ok = c.Type == Double
+	case int, int64:
+		ok = c.Type == Int64
+	case time.Time:
+		ok = c.Type == Timestamp
+	}
+
+	if !ok {
+		return fmt.Errorf("column %s can't hold a %T", c.Name, v)
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group
+func (w *Writer) flush() error {
+	if len(w.rows) == 0 {
+		return w.err
+	}
+
+	if w.written == 0 {
+		w.write([]byte(magic))
+	}
+
+	chunks := make([]columnChunk, len(w.columns))
+	for n, c := range w.columns {
+		offset := w.written
+		w.writePage(c, n)
+		chunks[n] = columnChunk{offset: offset, size: w.written - offset, values: int64(len(w.rows))}
+	}
+
+	w.rowGroups = append(w.rowGroups, chunks)
+	w.numRows += int64(len(w.rows))
+	w.rows = w.rows[:0]
+
+	return w.err
+}
+
+// writePage writes one column of the buffered rows as a data page
+func (w *Writer) writePage(c Column, n int) {
+	levels := make([]byte, 0, len(w.rows))
+	var values []byte
+
+	for _, row := range w.rows {
+		v := row[n]
+		if v == nil {
+			levels = append(levels, 0)
+			continue
+		}
+		levels = append(levels, 1)
+
+		switch v := v.(type) {
+		case string:
+			values = binary.LittleEndian.AppendUint32(values, uint32(len(v)))
+			values = append(values, v...)
+		case float64:
+			values = binary.LittleEndian.AppendUint64(values, math.Float64bits(v))
+		case int:
+			values = binary.LittleEndian.AppendUint64(values, uint64(v))
+		case int64:
+			values = binary.LittleEndian.AppendUint64(values, uint64(v))
+		case time.Time:
+			values = binary.LittleEndian.AppendUint64(values, uint64(v.UnixMilli()))
+		}
+	}
+
+	// definition levels are length prefixed and rle encoded, with no repetition levels as nothing is nested
+	rle := encodeLevels(levels)
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(rle)))
+	page = append(page, rle...)
+	page = append(page, values...)
+
+	h := newThriftWriter()
+	h.i32(1, pageTypeData)
+	h.i32(2, int32(len(page)))
+	h.i32(3, int32(len(page)))
+	h.structField(5)
+	h.i32(1, int32(len(w.rows)))
+	h.i32(2, encodingPlain)
+	h.i32(3, encodingRLE)
+	h.i32(4, encodingRLE)
+	h.end()
+
+	w.write(h.bytes())
+	w.write(page)
+}
+
+// encodeLevels is the rle/bit packing hybrid encoding of 0/1 levels using only rle runs
+func encodeLevels(levels []byte) []byte {
+	var out []byte
+	for start := 0; start < len(levels); {
+		end := start
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		out = binary.AppendUvarint(out, uint64(end-start)<<1)
+		out = append(out, levels[start])
+		start = end
+	}
+	return out
+}
+
+// Close writes any buffered rows and the footer, it does not close the underlying writer
+func (w *Writer) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	if w.written == 0 {
+		w.write([]byte(magic))
+	}
+
+	m := newThriftWriter()
+	m.i32(1, 1)
+
+	m.list(2, thriftStruct, len(w.columns)+1)
+	m.elemStruct()
+	m.i32(3, repetitionRequired)
+	m.string(4, "schema")
+	m.i32(5, int32(len(w.columns)))
+	m.end()
+	for _, c := range w.columns {
+		physical, converted := c.types()
+		m.elemStruct()
+		m.i32(1, physical)
+		m.i32(3, repetitionOptional)
+		m.string(4, c.Name)
+		if converted >= 0 {
+			m.i32(6, converted)
+		}
+		m.end()
+	}
+
+	m.i64(3, w.numRows)
+
+	m.list(4, thriftStruct, len(w.rowGroups))
+	for _, chunks := range w.rowGroups {
+		m.elemStruct()
+
+		var size, rows int64
+		m.list(1, thriftStruct, len(chunks))
+		for n, chunk := range chunks {
+			physical, _ := w.columns[n].types()
+			size += chunk.size
+			rows = chunk.values
+
+			m.elemStruct()
+			m.i64(2, chunk.offset)
+			m.structField(3)
+			m.i32(1, physical)
+			m.list(2, thriftI32, 2)
+			m.elemI32(encodingPlain)
+			m.elemI32(encodingRLE)
+			m.list(3, thriftBinary, 1)
+			m.elemString(w.columns[n].Name)
+			m.i32(4, codecUncompressed)
+			m.i64(5, chunk.values)
+			m.i64(6, chunk.size)
+			m.i64(7, chunk.size)
+			m.i64(9, chunk.offset)
+			m.end()
+			m.end()
+		}
+
+		m.i64(2, size)
+		m.i64(3, rows)
+		m.end()
+	}
+
+	m.string(6, "gogo-jira-stats")
+
+	footer := m.bytes()
+	w.write(footer)
+	w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	w.write([]byte(magic))
+
+	return w.err
+}
+
+func (c Column) types() (physical int32, converted int32) {
+	switch c.Type {
+	case Double:
+		return physicalDouble, -1
+	case Int64:
+		return physicalInt64, -1
+	case Timestamp:
+		return physicalInt64, convertedTimestampMillis
+	default:
+		return physicalByteArray, convertedUTF8
+	}
+}
@@ -0,0 +1,162 @@
+package parquet_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/parquet"
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// the written files are read back with parquet-go, an independent implementation of the format
+func TestWriterRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	columns := []parquet.Column{
+		{Name: "key", Type: parquet.String},
+		{Name: "days", Type: parquet.Double},
+		{Name: "count", Type: parquet.Int64},
+		{Name: "closed", Type: parquet.Timestamp},
+	}
+
+	closed := time.Date(2024, 1, 2, 9, 30, 15, 123e6, time.UTC)
+	rows := [][]any{
+		{"AB-1", 1.5, int64(3), closed},
+		{nil, nil, nil, nil},
+		{"", -0.25, 0, closed.In(time.FixedZone("AEST", 10*60*60))},
+		{"AB-4 ünïcödé, with a comma", nil, 42, nil},
+		{"AB-5", 1e100, nil, closed.Add(-365 * 24 * time.Hour)},
+	}
+
+	var b bytes.Buffer
+	w := parquet.NewWriter(&b, columns)
+	w.RowGroupSize = 2 // rows across several row groups, the last one partly filled
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("writing %v: %v", row, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+
+	f, err := pq.OpenFile(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("opening the written file: %v", err)
+	}
+
+	if f.NumRows() != int64(len(rows)) || len(f.RowGroups()) != 3 {
+		t.Fatalf("expected %d rows in 3 row groups, got %d in %d", len(rows), f.NumRows(), len(f.RowGroups()))
+	}
+
+	// the schema root then a leaf per column
+	schema := f.Metadata().Schema
+	if len(schema) != len(columns)+1 {
+		t.Fatalf("expected %d schema elements, got %d", len(columns)+1, len(schema))
+	}
+	expected := []struct {
+		physical  format.Type
+		converted deprecated.ConvertedType
+	}{
+		{format.ByteArray, deprecated.UTF8},
+		{format.Double, -1},
+		{format.Int64, -1},
+		{format.Int64, deprecated.TimestampMillis},
+	}
+	for n, e := range expected {
+		el := schema[n+1]
+		if el.Name != columns[n].Name || el.Type == nil || *el.Type != e.physical || el.RepetitionType == nil || *el.RepetitionType != format.Optional {
+			t.Errorf("unexpected schema for %s: %+v", columns[n].Name, el)
+		}
+		if (e.converted < 0) != (el.ConvertedType == nil) || (el.ConvertedType != nil && *el.ConvertedType != e.converted) {
+			t.Errorf("expected %s to have converted type %v, got %v", columns[n].Name, e.converted, el.ConvertedType)
+		}
+	}
+
+	var got []pq.Row
+	for _, rg := range f.RowGroups() {
+		r := rg.Rows()
+		buf := make([]pq.Row, 4)
+		for {
+			n, err := r.ReadRows(buf)
+			for _, row := range buf[:n] {
+				got = append(got, row.Clone())
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("reading rows: %v", err)
+			}
+		}
+		r.Close() //nolint:errcheck,gosec
+	}
+	if len(got) != len(rows) {
+		t.Fatalf("expected %d rows, read %d", len(rows), len(got))
+	}
+
+	for n, row := range rows {
+		values := map[int]pq.Value{}
+		for _, v := range got[n] {
+			values[v.Column()] = v
+		}
+
+		for c, want := range row {
+			v := values[c]
+			if want == nil {
+				if !v.IsNull() {
+					t.Errorf("row %d %s: expected null, got %v", n, columns[c].Name, v)
+				}
+				continue
+			}
+			if v.IsNull() {
+				t.Errorf("row %d %s: expected %v, got null", n, columns[c].Name, want)
+				continue
+			}
+
+			switch want := want.(type) {
+			case string:
+				if string(v.ByteArray()) != want {
+					t.Errorf("row %d %s: expected %q, got %q", n, columns[c].Name, want, v.ByteArray())
+				}
+			case float64:
+				if v.Double() != want {
+					t.Errorf("row %d %s: expected %v, got %v", n, columns[c].Name, want, v.Double())
+				}
+			case int:
+				if v.Int64() != int64(want) {
+					t.Errorf("row %d %s: expected %d, got %d", n, columns[c].Name, want, v.Int64())
+				}
+			case int64:
+				if v.Int64() != want {
+					t.Errorf("row %d %s: expected %d, got %d", n, columns[c].Name, want, v.Int64())
+				}
+			case time.Time:
+				if got := time.UnixMilli(v.Int64()); !got.Equal(want) {
+					t.Errorf("row %d %s: expected %s, got %s", n, columns[c].Name, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWriterRejectsMismatchedValues(t *testing.T) {
+	t.Parallel()
+
+	w := parquet.NewWriter(io.Discard, []parquet.Column{{Name: "key", Type: parquet.String}, {Name: "days", Type: parquet.Double}})
+	for _, row := range [][]any{
+		{"AB-1"},
+		{"AB-1", 1.5, nil},
+		{1.5, "AB-1"},
+		{"AB-1", time.Now()},
+	} {
+		if err := w.Write(row); err == nil {
+			t.Errorf("expected %v to be rejected", row)
+		}
+	}
+}
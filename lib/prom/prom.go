@@ -0,0 +1,161 @@
+package prom
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// a minimal writer for the prometheus text exposition format, enough to publish gauges, counters and histograms
+// computed on each scrape without pulling in the full client library
+
+type Labels map[string]string
+
+type sample struct {
+	labels Labels
+	value  float64
+}
+
+type Metric struct {
+	Name string
+	Help string
+	Type string
+
+	samples []sample
+	buckets []float64
+	hists   map[string]*histogram
+	order   []string
+}
+
+type histogram struct {
+	labels Labels
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func NewGauge(name, help string) *Metric {
+	return &Metric{Name: name, Help: help, Type: "gauge"}
+}
+
+func NewCounter(name, help string) *Metric {
+	return &Metric{Name: name, Help: help, Type: "counter"}
+}
+
+func NewHistogram(name, help string, buckets []float64) *Metric {
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+
+	return &Metric{Name: name, Help: help, Type: "histogram", buckets: b, hists: map[string]*histogram{}}
+}
+
+// Set records a gauge or counter value for a set of labels
+func (m *Metric) Set(value float64, labels Labels) {
+	m.samples = append(m.samples, sample{labels: labels, value: value})
+}
+
+// Observe adds a value to the histogram for a set of labels
+func (m *Metric) Observe(value float64, labels Labels) {
+	k := labels.String()
+	h, ok := m.hists[k]
+	if !ok {
+		h = &histogram{labels: labels, counts: make([]uint64, len(m.buckets))}
+		m.hists[k] = h
+		m.order = append(m.order, k)
+	}
+
+	for i, b := range m.buckets {
+		if value <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (m *Metric) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# HELP %s %s\n", m.Name, escapeHelp(m.Help))
+	fmt.Fprintf(&sb, "# TYPE %s %s\n", m.Name, m.Type)
+
+	sort.SliceStable(m.samples, func(i, j int) bool {
+		return m.samples[i].labels.String() < m.samples[j].labels.String()
+	})
+	for _, s := range m.samples {
+		fmt.Fprintf(&sb, "%s%s %s\n", m.Name, s.labels.String(), formatFloat(s.value))
+	}
+
+	for _, k := range m.order {
+		h := m.hists[k]
+		for i, b := range m.buckets {
+			fmt.Fprintf(&sb, "%s_bucket%s %d\n", m.Name, h.labels.with("le", formatFloat(b)).String(), h.counts[i])
+		}
+		fmt.Fprintf(&sb, "%s_bucket%s %d\n", m.Name, h.labels.with("le", "+Inf").String(), h.count)
+		fmt.Fprintf(&sb, "%s_sum%s %s\n", m.Name, h.labels.String(), formatFloat(h.sum))
+		fmt.Fprintf(&sb, "%s_count%s %d\n", m.Name, h.labels.String(), h.count)
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// Write outputs all metrics in order
+func Write(w io.Writer, metrics ...*Metric) error {
+	for _, m := range metrics {
+		if _, err := m.WriteTo(w); err != nil {
+			return fmt.Errorf("writing metric %s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+func (l Labels) with(k, v string) Labels {
+	n := Labels{k: v}
+	for lk, lv := range l {
+		n[lk] = lv
+	}
+	return n
+}
+
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+`="`+escapeLabel(l[k])+`"`)
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
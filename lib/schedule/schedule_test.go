@@ -0,0 +1,115 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/katbyte/gogo-jira-stats/lib/schedule"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		expr  string
+		valid bool
+	}{
+		{"2m", true},
+		{"1h30m", true},
+		{"0s", false},
+		{"-5m", false},
+		{"*/15 * * * *", true},
+		{"0 9-17 * * mon-fri", true},
+		{"0 0 1,15 jan,jul *", true},
+		{"0 0 * * 7", true},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"* * * * funday", false},
+		{"a * * * *", false},
+	}
+
+	for _, tc := range cases {
+		_, err := schedule.Parse(tc.expr)
+		if tc.valid && err != nil {
+			t.Errorf("expected %q to parse: %v", tc.expr, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("expected %q to be invalid", tc.expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	// 2024-01-31 is a wednesday
+	cases := []struct {
+		name, expr, from, next string
+	}{
+		{"every 15 minutes", "*/15 * * * *", "2024-01-31 10:07", "2024-01-31 10:15"},
+		{"step wraps the hour", "*/15 * * * *", "2024-01-31 10:45", "2024-01-31 11:00"},
+		{"step on a range", "10-50/20 * * * *", "2024-01-31 10:31", "2024-01-31 10:50"},
+		{"always after from", "30 10 * * *", "2024-01-31 10:30", "2024-02-01 10:30"},
+		{"hour range", "0 9-17 * * *", "2024-01-31 17:01", "2024-02-01 09:00"},
+		{"list", "0 8,12,18 * * *", "2024-01-31 12:00", "2024-01-31 18:00"},
+		{"weekday names", "0 9 * * mon-fri", "2024-02-02 10:00", "2024-02-05 09:00"},
+		{"day names are case insensitive", "0 9 * * SAT", "2024-01-31 10:00", "2024-02-03 09:00"},
+		{"7 is sunday", "0 0 * * 7", "2024-01-31 10:00", "2024-02-04 00:00"},
+		{"month names", "0 0 1 jul *", "2024-01-31 10:00", "2024-07-01 00:00"},
+		{"across a month end", "0 0 * * *", "2024-01-31 23:59", "2024-02-01 00:00"},
+		{"across a year end", "0 0 1 * *", "2024-12-31 12:00", "2025-01-01 00:00"},
+		{"31st skips short months", "0 0 31 * *", "2024-01-31 10:00", "2024-03-31 00:00"},
+		{"leap day", "0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"day of month or day of week", "0 0 15 * fri", "2024-01-31 10:00", "2024-02-02 00:00"},
+		{"day of week or day of month", "0 0 1 * sun", "2024-01-31 10:00", "2024-02-01 00:00"},
+		{"restricted day of month only", "0 0 15 * *", "2024-01-31 10:00", "2024-02-15 00:00"},
+	}
+
+	for _, tc := range cases {
+		s, err := schedule.ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: parsing %q: %v", tc.name, tc.expr, err)
+		}
+		if got := s.Next(at(tc.from)); !got.Equal(at(tc.next)) {
+			t.Errorf("%s: expected %q after %s to be %s, got %s", tc.name, tc.expr, tc.from, tc.next, got.Format("2006-01-02 15:04"))
+		}
+	}
+
+	// an expression that can never match gives up rather than spinning
+	s, err := schedule.ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(at("2024-01-31 10:00")); !got.IsZero() {
+		t.Errorf("expected 31 february to never fire, got %s", got)
+	}
+}
+
+func TestEveryNext(t *testing.T) {
+	t.Parallel()
+
+	s, err := schedule.Parse("90m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(from.Add(90 * time.Minute)) {
+		t.Errorf("expected 90m after %s, got %s", from, got)
+	}
+}
@@ -4,16 +4,5 @@
 make
 make install
 
-#copy env
-env >> /etc/profile
-
-#setup cron
-echo "$SYNC_CRON /app/scripts/run.sh 2>&1 | tee -a /var/log/gogo-jira-stats.log" > /etc/cron.d/crontab
-chmod 0644 /etc/cron.d/crontab
-crontab /etc/cron.d/crontab
-touch /var/log/cron.log
-
-# start cron
-/usr/sbin/crond -b
-touch /var/log/gogo-j-stats.log
-tail -f /var/log/gogo-j-stats.log
\ No newline at end of file
+# run the sync daemon in place of this shell so it receives SIGTERM, it handles the schedule ($SYNC_CRON) itself
+exec gogo-jira-stats daemon
@@ -0,0 +1,25 @@
+#!/bin/bash
+
+# post a recorded jira webhook payload to a running `serve`, signed the same way jira does
+
+if [ $# -lt 1 ] ; then
+  echo "usage: $0 <payload.json> [url]"
+  echo "  signs with \$JIRA_WEBHOOK_SECRET, url defaults to http://localhost:8080/webhooks/jira"
+  exit 1
+fi
+
+payload=$1
+url=${2:-http://localhost:8080/webhooks/jira}
+
+if [ -z "$JIRA_WEBHOOK_SECRET" ] ; then
+  echo "JIRA_WEBHOOK_SECRET must be set"
+  exit 1
+fi
+
+sig=$(openssl dgst -sha256 -hmac "$JIRA_WEBHOOK_SECRET" < "$payload" | sed 's/^.* //')
+
+curl -sS -X POST "$url" \
+  -H "Content-Type: application/json" \
+  -H "X-Hub-Signature: sha256=$sig" \
+  --data-binary "@$payload" \
+  -w "%{http_code}\n"
@@ -1,6 +1,8 @@
 #!/bin/sh
 
+# one off sync, the daemon command does this on a schedule
+
 echo
 echo "Job started: $(date)"
-gogo-jira-stats
+gogo-jira-stats fetch && gogo-jira-stats graphs
 echo "Job finished: $(date)"